@@ -0,0 +1,158 @@
+package ezcli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// AddConfigCommands adds a config command to manage config files
+//
+//	config init - writes a config file with the default value of every option
+//	config show - prints the value of every option and where it was set
+//	config get - prints the value of an option
+//	config set - sets an option in the config file
+//	config unset - removes an option from the config file
+//	config schema - prints a JSON Schema for the config file
+//	config migrate - upgrades a config file to the current version
+//	config encrypt - prints an encrypted config value
+//	config decrypt - prints a decrypted config value
+func (a *App) AddConfigCommands() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the config file",
+	}
+	cmd.AddCommand(a.configInitCmd())
+	cmd.AddCommand(a.configShowCmd())
+	cmd.AddCommand(a.configGetCmd())
+	cmd.AddCommand(a.configSetCmd())
+	cmd.AddCommand(a.configUnsetCmd())
+	cmd.AddCommand(a.configSchemaCmd())
+	cmd.AddCommand(a.configMigrateCmd())
+	cmd.AddCommand(a.configEncryptCmd())
+	cmd.AddCommand(a.configDecryptCmd())
+	a.Cmd.AddCommand(cmd)
+	a.configCmd = cmd
+	return cmd
+}
+
+// defaultConfigPath is where config init writes when no path is given
+func (a *App) defaultConfigPath(format string) string {
+	root := a.root()
+	if root.config == nil {
+		return "config." + format
+	}
+	if root.config.path != "" {
+		return root.config.path
+	}
+	name := root.config.name
+	if name == "" {
+		name = "config"
+	}
+	return name + "." + format
+}
+
+func (a *App) configInitCmd() *cobra.Command {
+	var format string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init [path]",
+		Short: "Write a config file with the default value of every option",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := a.defaultConfigPath(format)
+			if len(args) > 0 {
+				path = args[0]
+			}
+			// Use the file extension unless a format was asked for
+			// A format that doesn't match the extension would write a file that can't be read
+			ext := formatOf(path)
+			switch {
+			case !cmd.Flags().Changed("format") && ext != "":
+				format = ext
+			case isConfigFormat(ext) && ext != format:
+				return errors.Errorf("format %s doesn't match the extension of %s", format, path)
+			}
+
+			if fileExists(path) && !force {
+				return errors.Errorf("config file %s already exists, use --force to overwrite it", path)
+			}
+
+			root := a.root()
+			section := root.defaultsSection()
+			if version := root.configVersion(); version > 0 {
+				section = withVersion(section, version)
+			}
+			b, err := encodeConfig(section, format)
+			if err != nil {
+				return err
+			}
+			err = os.MkdirAll(filepath.Dir(path), 0o755)
+			if err != nil {
+				return errors.Wrapf(err, "unable to create directory for %s", path)
+			}
+			err = os.WriteFile(path, b, 0o644)
+			if err != nil {
+				return errors.Wrapf(err, "unable to write config file %s", path)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Wrote config file:", path)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", formatYAML, "config file format: yaml, toml or json, defaults to the file extension")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing config file")
+	return cmd
+}
+
+func (a *App) configShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Print the value of every option and where it was set",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			a.root().writeValues(w)
+			return w.Flush()
+		},
+	}
+}
+
+// writeValues writes a row with the config key of every variable in the App tree
+func (a *App) writeValues(w io.Writer) {
+	a.RLock()
+	defer a.RUnlock()
+	seen := make(map[string]bool)
+	a.visit(func(app *App) {
+		for _, v := range app.vars {
+			key := app.key(v.opts)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			fmt.Fprintf(w, "%s\t%v\t%s\n", key, app.redactVar(v.opts, configValue(v.value.Interface())), app.Source(v.opts.Name))
+		}
+	})
+}
+
+func (a *App) configSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema for the config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := a.JSONSchema()
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(b)
+			return err
+		},
+	}
+}
@@ -0,0 +1,233 @@
+package ezcli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// runCmd runs the App's command with args, loading the App like Execute
+func runCmd(t *testing.T, app *App, args ...string) (string, error) {
+	t.Helper()
+	app.Cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return app.loadCmd(cmd)
+	}
+	out := &strings.Builder{}
+	app.Cmd.SetOut(out)
+	app.Cmd.SetErr(out)
+	app.Cmd.SetArgs(args)
+	err := app.Cmd.Execute()
+	return out.String(), err
+}
+
+type configInitVars struct {
+	name    string
+	count   int
+	enabled bool
+	wait    time.Duration
+	tags    []string
+	child   string
+}
+
+func configInitApp(v *configInitVars, defaults bool) *App {
+	d := &configInitVars{"default", 1337, true, 5 * time.Second, []string{"a", "b"}, "child"}
+	if !defaults {
+		d = &configInitVars{}
+	}
+	app := New(&cobra.Command{Use: "tool"})
+	app.StringVar(&v.name, "name", d.name, "name to use\nover two lines")
+	app.IntVar(&v.count, "server.count", d.count, "number of servers")
+	app.BoolVar(&v.enabled, "enabled", d.enabled, "")
+	app.DurationVar(&v.wait, "wait", d.wait, "time to wait")
+	app.Var(&v.tags, "tags", d.tags, "tags to apply")
+	child := app.Child(New(&cobra.Command{Use: "child"}))
+	child.StringVar(&v.child, "value", d.child, "child value")
+	// Children without variables have no section
+	app.Child(New(&cobra.Command{Use: "empty"}))
+	return app
+}
+
+func TestApp_ConfigInit(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool."+format)
+			app := configInitApp(&configInitVars{}, true)
+			app.AddConfigCommands()
+			_, err := runCmd(t, app, "config", "init", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Read the file back without any defaults
+			got := &configInitVars{}
+			reader := configInitApp(got, false)
+			reader.Init(path, "")
+			err = reader.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := &configInitVars{"default", 1337, true, 5 * time.Second, []string{"a", "b"}, "child"}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("expected %+v got %+v", expected, got)
+			}
+
+			settings, err := readConfigFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := settings["child"].(map[string]any); !ok {
+				t.Errorf("expected a section for the child got %+v", settings)
+			}
+			if _, ok := settings["empty"]; ok {
+				t.Errorf("expected no section for an empty child got %+v", settings)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigInitComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool.yaml")
+	app := configInitApp(&configInitVars{}, true)
+	app.AddConfigCommands()
+	_, err := runCmd(t, app, "config", "init", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comment := range []string{"# name to use\n# over two lines\nname:", "  # number of servers\n  count:"} {
+		if !strings.Contains(string(b), comment) {
+			t.Errorf("expected config to contain %q got:\n%s", comment, b)
+		}
+	}
+}
+
+func TestApp_ConfigInitOverwrite(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: old\n")
+	app := configInitApp(&configInitVars{}, true)
+	app.AddConfigCommands()
+
+	_, err := runCmd(t, app, "config", "init", path)
+	if err == nil {
+		t.Error("expected an error overwriting an existing file")
+	}
+	_, err = runCmd(t, app, "config", "init", "--force", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "# name to use") {
+		t.Errorf("expected a yaml config got:\n%s", b)
+	}
+}
+
+func TestApp_ConfigInitFormat(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		initPath string
+		args     []string
+		path     string
+		expected string
+	}{
+		{"extension", "", []string{filepath.Join(dir, "a.toml")}, filepath.Join(dir, "a.toml"), ""},
+		{"no extension", "", []string{"--format", formatJSON, filepath.Join(dir, "b")}, filepath.Join(dir, "b"), ""},
+		{"matching", "", []string{"--format", formatYAML, filepath.Join(dir, "c.yml")}, filepath.Join(dir, "c.yml"), ""},
+		{"mismatch", "", []string{"--format", formatTOML, filepath.Join(dir, "d.yaml")}, "", "format toml doesn't match the extension of " + filepath.Join(dir, "d.yaml")},
+		{"init path mismatch", filepath.Join(dir, "e.yaml"), []string{"--format", formatTOML}, "", "format toml doesn't match the extension of " + filepath.Join(dir, "e.yaml")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := configInitApp(&configInitVars{}, true)
+			if test.initPath != "" {
+				app.Init(test.initPath, "")
+			}
+			app.AddConfigCommands()
+			_, err := runCmd(t, app, append([]string{"config", "init"}, test.args...)...)
+			if test.expected != "" {
+				if err == nil || err.Error() != test.expected {
+					t.Errorf("expected '%s' got '%v'", test.expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !fileExists(test.path) {
+				t.Errorf("expected %s to be written", test.path)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigInitSensitive(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool."+format)
+			app := New(&cobra.Command{Use: "tool"})
+			var token string
+			var port int
+			app.genericVar(&token, VarName("token"), VarDefaultValue("s3cr3t-default"), VarSensitive())
+			app.genericVar(&port, VarName("port"), VarDefaultValue(1234), VarSensitive())
+			app.AddConfigCommands()
+			_, err := runCmd(t, app, "config", "init", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(b), "s3cr3t-default") || strings.Contains(string(b), "1234") {
+				t.Errorf("expected sensitive defaults to be left out got:\n%s", b)
+			}
+			settings, err := readConfigFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := settings["token"]; !ok {
+				t.Errorf("expected the sensitive key to be written got '%v'", settings)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigInitMap(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool."+format)
+			defaults := map[string]int{"cpu": 2, "memory": 512}
+			app := New(&cobra.Command{Use: "tool"})
+			var limits map[string]int
+			app.Var(&limits, "limits", defaults, "usage")
+			app.AddConfigCommands()
+			_, err := runCmd(t, app, "config", "init", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			app = New(&cobra.Command{Use: "tool"})
+			app.Var(&limits, "limits", map[string]int(nil), "usage")
+			app.Init(path, "")
+			err = app.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(limits, defaults) {
+				t.Errorf("expected '%v' got '%v'", defaults, limits)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package ezcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	configFlag      = "config"
+	configEnvSuffix = "_CONFIG"
+)
+
+// configOpts describes where an App looks for its configuration files
+type configOpts struct {
+	path       string        // Default path provided to Init, skipped if it does not exist
+	name       string        // File name to discover without an extension eg: config for config.yaml
+	layers     []ConfigLayer // Files merged beneath the main config file
+	flagPath   string        // Path provided through the --config flag
+	configLoad               // The last config that was applied
+}
+
+// configLoad is the config read by a single load, it is built without locking the App then applied
+type configLoad struct {
+	settings  map[string]any    // Merged config of every file and provider
+	files     []string          // Files read, lowest priority first
+	sources   map[string]Source // File or provider each flattened key was last set by
+	unknown   []unknownKey      // Keys that don't belong to a variable
+	encrypted map[string]bool   // Flattened keys whose values were decrypted
+}
+
+// ConfigLayer is a config file deep-merged over the layers before it
+type ConfigLayer struct {
+	Path     string // Path to the file, a leading ~ is expanded to the home directory
+	Required bool   // Fail to load if the file does not exist
+}
+
+// DefaultConfigLayers are the optional system, user and project config files for an app
+func DefaultConfigLayers(app string) []ConfigLayer {
+	return []ConfigLayer{
+		{Path: filepath.Join("/etc", app, "config.yaml")},
+		{Path: filepath.Join("~", ".config", app, "config.yaml")},
+		{Path: "." + app + ".yaml"},
+	}
+}
+
+// configEnv is the environment variable that can override the config file path
+// This is <PREFIX>_CONFIG when the App has an environment variable prefix
+func (a *App) configEnv() string {
+	return a.appEnv(configEnvSuffix)
+}
+
+// configSearchPaths are the directories searched, in order, for a config file
+func (a *App) configSearchPaths() []string {
+	paths := []string{"."}
+	app := a.Cmd.Name()
+
+	home, err := homedir.Dir()
+	if err != nil {
+		home = ""
+	}
+
+	if app != "" {
+		xdg := os.Getenv("XDG_CONFIG_HOME")
+		if xdg == "" && home != "" {
+			xdg = filepath.Join(home, ".config")
+		}
+		if xdg != "" {
+			paths = append(paths, filepath.Join(xdg, app))
+		}
+	}
+	if home != "" {
+		paths = append(paths, home)
+	}
+	if app != "" {
+		paths = append(paths, filepath.Join("/etc", app))
+	}
+	return paths
+}
+
+// configFile returns the config file to read and whether it must exist
+// An empty path means no config file was provided or discovered
+func (a *App) configFile() (string, bool) {
+	// Paths provided by the user must exist
+	if a.config.flagPath != "" {
+		return a.config.flagPath, true
+	}
+	if path := os.Getenv(a.configEnv()); path != "" {
+		return path, true
+	}
+
+	// Everything else is optional
+	if a.config.path != "" && fileExists(a.config.path) {
+		return a.config.path, false
+	}
+	if a.config.name == "" {
+		return "", false
+	}
+	for _, dir := range a.configSearchPaths() {
+		for _, ext := range viper.SupportedExts {
+			path := filepath.Join(dir, a.config.name+"."+ext)
+			if fileExists(path) {
+				return path, false
+			}
+		}
+	}
+	return "", false
+}
+
+// configLayers are every layer to merge in order, ending with the main config file
+func (a *App) configLayers() []ConfigLayer {
+	layers := append([]ConfigLayer{}, a.config.layers...)
+	path, required := a.configFile()
+	if path != "" {
+		layers = append(layers, ConfigLayer{Path: path, Required: required})
+	}
+	return layers
+}
+
+// readConfig merges every config layer and provider without changing the App
+// Providers may be slow so this is done before the App is locked
+func (a *App) readConfig() (*configLoad, error) {
+	load := &configLoad{
+		settings:  make(map[string]any),
+		sources:   make(map[string]Source),
+		encrypted: make(map[string]bool),
+	}
+	confDirs := make(map[string]bool)
+
+	for _, layer := range a.configLayers() {
+		path, err := homedir.Expand(layer.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to expand config path %s", layer.Path)
+		}
+		if !fileExists(path) {
+			if !layer.Required {
+				continue
+			}
+			return nil, errors.Errorf("config file %s does not exist", path)
+		}
+
+		version, err := a.readConfigFiles(load, path, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		// Fragments in conf.d take priority over the file next to them
+		dir := confDirOf(path)
+		if confDirs[dir] {
+			continue
+		}
+		confDirs[dir] = true
+		for _, fragment := range confFragments(dir) {
+			_, err = a.readConfigFiles(load, fragment, nil, &version)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The profile is part of the files, so providers take priority over it too
+	err := a.applyProfile(load)
+	if err != nil {
+		return nil, err
+	}
+	err = a.loadProviders(load)
+	if err != nil {
+		return nil, err
+	}
+	err = a.interpolateConfig(load)
+	if err != nil {
+		return nil, err
+	}
+	err = a.decryptConfig(load)
+	if err != nil {
+		return nil, err
+	}
+	err = a.checkUnknownKeys(load.unknown)
+	if err != nil {
+		return nil, err
+	}
+	return load, nil
+}
+
+// applyConfig replaces the App's config with a config that has been read
+func (a *App) applyConfig(load *configLoad) error {
+	a.config.configLoad = *load
+	return a.setConfig(load.settings)
+}
+
+// mergeConfig merges src into the settings and records it as the source of its keys
+func (a *App) mergeConfig(load *configLoad, src map[string]any, source Source) {
+	load.unknown = append(load.unknown, a.findUnknownKeys(src, source)...)
+	mergeMaps(load.settings, src)
+	for _, key := range flattenKeys(src, "") {
+		load.sources[key] = source
+	}
+}
+
+// setConfig replaces the config held by every App's Viper
+// Child Apps share the root's config and read their own section of it
+func (a *App) setConfig(settings map[string]any) error {
+	file := ""
+	if len(a.config.files) > 0 {
+		file = a.config.files[len(a.config.files)-1]
+	}
+	var err error
+	a.visit(func(app *App) {
+		if err == nil {
+			err = setViperConfig(app.Viper, settings, file)
+		}
+	})
+	return err
+}
+
+func setViperConfig(v *viper.Viper, settings map[string]any, file string) error {
+	// Viper can only clear its config by reading in a new one
+	v.SetConfigType("json")
+	err := v.ReadConfig(strings.NewReader("{}"))
+	if err != nil {
+		return errors.Wrap(err, "unable to reset config")
+	}
+	// Keep Viper pointing at the main config file
+	if file != "" {
+		v.SetConfigFile(file)
+		v.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+	}
+	return v.MergeConfigMap(settings)
+}
+
+// readConfigFile reads a single config file, the format is taken from its extension
+func readConfigFile(path string) (map[string]any, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read config file %s", path)
+	}
+	return v.AllSettings(), nil
+}
+
+// mergeMaps deep-merges src into dst, nested maps are merged rather than replaced
+func mergeMaps(dst, src map[string]any) {
+	for key, srcVal := range src {
+		srcMap, srcIsMap := srcVal.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = srcVal
+	}
+}
+
+// flattenKeys are the dot separated paths to every value in a nested map
+func flattenKeys(m map[string]any, prefix string) []string {
+	keys := make([]string, 0, len(m))
+	for key, val := range m {
+		key = strings.ToLower(prefix + key)
+		if nested, ok := val.(map[string]any); ok && len(nested) > 0 {
+			keys = append(keys, flattenKeys(nested, key+".")...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// lookupMap finds the nested map at a flattened key
+func lookupMap(settings map[string]any, key string) (map[string]any, bool) {
+	current := settings
+	for _, part := range strings.Split(key, ".") {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// lookupValue finds the value of a flattened key in nested maps
+func lookupValue(settings map[string]any, key string) (any, bool) {
+	parent, last := settings, key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		var ok bool
+		parent, ok = lookupMap(settings, key[:i])
+		if !ok {
+			return nil, false
+		}
+		last = key[i+1:]
+	}
+	value, ok := parent[last]
+	return value, ok
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,255 @@
+package ezcli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApp_InitConfig(t *testing.T) {
+	dir := t.TempDir()
+	defaultFile := writeFile(t, filepath.Join(dir, "default.yaml"), "name: default-file\n")
+	flagFile := writeFile(t, filepath.Join(dir, "flag.json"), `{"name":"flag-file"}`)
+	envFile := writeFile(t, filepath.Join(dir, "env.toml"), `name = "env-file"`)
+	xdg := t.TempDir()
+	writeFile(t, filepath.Join(xdg, "tool", "tool.yaml"), "name: xdg-file\n")
+
+	tests := []struct {
+		name     string
+		path     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"default path", defaultFile, nil, "", "default-file"},
+		{"flag", defaultFile, []string{"--config=" + flagFile}, "", "flag-file"},
+		{"env", defaultFile, nil, envFile, "env-file"},
+		{"flag over env", defaultFile, []string{"--config=" + flagFile}, envFile, "flag-file"},
+		{"discovered", filepath.Join(dir, "missing.yaml"), nil, "", "xdg-file"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", xdg)
+			t.Setenv("TOOL_CONFIG", test.env)
+
+			app := New(&cobra.Command{Use: "tool"})
+			var name string
+			app.StringVar(&name, "name", "", "usage")
+			app.Init(test.path, "tool")
+			err := app.Cmd.ParseFlags(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = app.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name != test.expected {
+				t.Errorf("expected '%s' got '%s'", test.expected, name)
+			}
+		})
+	}
+}
+
+func TestApp_InitConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	malformed := writeFile(t, filepath.Join(dir, "malformed.json"), `{"name":`)
+
+	tests := []struct {
+		name    string
+		path    string
+		args    []string
+		wantErr bool
+	}{
+		{"missing optional", filepath.Join(dir, "missing.yaml"), nil, false},
+		{"missing from flag", "", []string{"--config=" + filepath.Join(dir, "missing.yaml")}, true},
+		{"malformed", malformed, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+			app := New(&cobra.Command{Use: "tool"})
+			app.Init(test.path, "doesntexist")
+			err := app.Cmd.ParseFlags(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = app.load()
+			if (err != nil) != test.wantErr {
+				t.Errorf("expected error %t got '%v'", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestApp_InitConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	system := writeFile(t, filepath.Join(dir, "etc", "config.yaml"), `
+server:
+  host: system.example.com
+  port: 80
+timeout: 5s
+`)
+	user := writeFile(t, filepath.Join(dir, "user", "config.json"), `{"server":{"port":8080}}`)
+	project := writeFile(t, filepath.Join(dir, "project.yaml"), "timeout: 10s\n")
+
+	app := New(&cobra.Command{Use: "tool"})
+	var host, timeout string
+	var port int
+	app.StringVar(&host, "server.host", "", "usage")
+	app.IntVar(&port, "server.port", 0, "usage")
+	app.StringVar(&timeout, "timeout", "", "usage")
+	app.Init("", "",
+		ConfigLayer{Path: system, Required: true},
+		ConfigLayer{Path: filepath.Join(dir, "missing.yaml")},
+		ConfigLayer{Path: user},
+		ConfigLayer{Path: project},
+	)
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "system.example.com" {
+		t.Errorf("expected host from system layer got '%s'", host)
+	}
+	if port != 8080 {
+		t.Errorf("expected port from user layer got '%d'", port)
+	}
+	if timeout != "10s" {
+		t.Errorf("expected timeout from project layer got '%s'", timeout)
+	}
+}
+
+func TestApp_InitConfigLayersRequired(t *testing.T) {
+	app := New(&cobra.Command{Use: "tool"})
+	app.Init("", "", ConfigLayer{Path: filepath.Join(t.TempDir(), "missing.yaml"), Required: true})
+
+	err := app.load()
+	if err == nil {
+		t.Error("expected an error for a missing required layer")
+	}
+}
+
+func TestApp_ChildConfigKeys(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), `
+name: root
+verbose: true
+foo:
+  name: foo
+  bar:
+    name: bar
+`)
+	app := New(&cobra.Command{Use: "tool"})
+	foo := New(&cobra.Command{Use: "foo"})
+	bar := New(&cobra.Command{Use: "bar"})
+	var rootName, fooName, barName string
+	var verbose bool
+	app.StringVar(&rootName, "name", "", "usage")
+	foo.StringVar(&fooName, "name", "", "usage")
+	// Register before the chain of commands is known
+	bar.StringVar(&barName, "name", "", "usage")
+	bar.genericVar(&verbose, VarName("verbose"), VarSharedKey())
+	foo.Child(bar)
+	app.Child(foo)
+
+	app.Init(path, "")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct{ expected, got string }{
+		{"root", rootName},
+		{"foo", fooName},
+		{"bar", barName},
+	} {
+		if test.expected != test.got {
+			t.Errorf("expected '%s' got '%s'", test.expected, test.got)
+		}
+	}
+	if !verbose {
+		t.Error("expected shared key to be read from the top level")
+	}
+	if source := bar.Source("name"); source.Kind != SourceFile {
+		t.Errorf("expected child value from file got '%s'", source)
+	}
+}
+
+func TestApp_UseConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "tool", "tool.yaml"), "name: from-file\n")
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	app := New(&cobra.Command{Use: "tool"}, AppUseConfig())
+	var name string
+	app.StringVar(&name, "name", "", "usage")
+	if app.Cmd.PersistentFlags().Lookup(configFlag) == nil {
+		t.Fatal("expected a config flag")
+	}
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "from-file" {
+		t.Errorf("expected 'from-file' got '%s'", name)
+	}
+
+	// Init can still change where we look
+	app.Init(filepath.Join(dir, "missing.yaml"), "missing")
+}
+
+func TestApp_MapConfigMerge(t *testing.T) {
+	dir := t.TempDir()
+	system := writeFile(t, filepath.Join(dir, "system.yaml"), "labels:\n  env: prod\n  team: core\n")
+	user := writeFile(t, filepath.Join(dir, "user.json"), `{"labels":{"team":"edge"}}`)
+
+	tests := []struct {
+		name     string
+		env      string
+		expected map[string]string
+	}{
+		{"layers merge keys", "", map[string]string{"env": "prod", "team": "edge"}},
+		{"env replaces the map", "owner=ops", map[string]string{"owner": "ops"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TOOL_LABELS", test.env)
+			app := New(&cobra.Command{Use: "tool"}, AppEnvPrefix("tool"))
+			var labels map[string]string
+			app.Var(&labels, "labels", map[string]string(nil), "usage")
+			app.Init("", "", ConfigLayer{Path: system}, ConfigLayer{Path: user})
+
+			err := app.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(labels, test.expected) {
+				t.Errorf("expected '%v' got '%v'", test.expected, labels)
+			}
+		})
+	}
+}
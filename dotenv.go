@@ -0,0 +1,128 @@
+package ezcli
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	envFileFlag    = "env-file"
+	cleanEnvFlag   = "clean-env"
+	defaultEnvFile = ".env"
+)
+
+// EnvFileOpts describes the dotenv files loaded before environment variables are read
+type EnvFileOpts struct {
+	Files    []string // Files that must exist, loaded before any --env-file flags
+	Discover bool     // Load ./.env if it exists
+	Override bool     // Values from files replace variables that are already in the environment
+}
+
+// envFiles holds the dotenv options and flags for an App
+type envFiles struct {
+	opts   EnvFileOpts
+	files  []string          // Files from the --env-file flag
+	clean  bool              // Ignore the inherited environment
+	loaded map[string]string // Variables read from every file
+}
+
+// addEnvFileFlags adds the --env-file and --clean-env flags
+func (a *App) addEnvFileFlags(opts EnvFileOpts) {
+	a.envFiles = &envFiles{opts: opts}
+	flags := a.Cmd.PersistentFlags()
+	flags.StringArrayVar(&a.envFiles.files, envFileFlag, nil, "dotenv file to load, can be repeated")
+	flags.BoolVar(&a.envFiles.clean, cleanEnvFlag, false, "ignore inherited environment variables, only env files are used")
+}
+
+// loadEnvFiles sets environment variables from every dotenv file, later files take priority
+func (a *App) loadEnvFiles() error {
+	if a.envFiles == nil {
+		return nil
+	}
+	paths := append([]string{}, a.envFiles.opts.Files...)
+	if a.envFiles.opts.Discover && fileExists(defaultEnvFile) {
+		paths = append(paths, defaultEnvFile)
+	}
+	paths = append(paths, a.envFiles.files...)
+
+	env := make(map[string]string)
+	for _, path := range paths {
+		fileEnv, err := readEnvFile(path)
+		if err != nil {
+			return err
+		}
+		for key, val := range fileEnv {
+			env[key] = val
+		}
+	}
+
+	a.envFiles.loaded = env
+
+	// Only the variables we read are cleaned, everything else is left for the rest of the process
+	if a.envFiles.clean {
+		for _, name := range a.envNames() {
+			if _, ok := env[name]; !ok {
+				os.Unsetenv(name)
+			}
+		}
+	}
+
+	for key, val := range env {
+		if _, exists := os.LookupEnv(key); exists && !a.envFiles.opts.Override && !a.envFiles.clean {
+			continue
+		}
+		err := os.Setenv(key, val)
+		if err != nil {
+			return errors.Wrapf(err, "unable to set %s", key)
+		}
+	}
+	return nil
+}
+
+// lookupEnv reads an environment variable, only from the env files when the environment is clean
+func (a *App) lookupEnv(name string) (string, bool) {
+	envFiles := a.root().envFiles
+	if envFiles != nil && envFiles.clean {
+		val, ok := envFiles.loaded[name]
+		return val, ok
+	}
+	return os.LookupEnv(name)
+}
+
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open env file %s", path)
+	}
+	defer f.Close()
+	env, err := parseDotenv(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse env file %s", path)
+	}
+	return env, nil
+}
+
+// envNames are every environment variable the App tree reads
+func (a *App) envNames() []string {
+	names := make([]string, 0)
+	if a.config != nil {
+		names = append(names, a.configEnv(), a.configKeyEnv(), a.configKeyEnv()+secretFileEnv)
+	}
+	if a.opts.profiles {
+		names = append(names, a.profileEnv())
+	}
+	a.visit(func(app *App) {
+		for _, v := range app.vars {
+			env := app.env(v.opts)
+			if env == "" {
+				continue
+			}
+			names = append(names, env)
+			if v.opts.Sensitive {
+				names = append(names, env+secretFileEnv)
+			}
+		}
+	})
+	return names
+}
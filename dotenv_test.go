@@ -0,0 +1,146 @@
+package ezcli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// unsetEnv removes environment variables for the test, restoring them afterwards
+func unsetEnv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestApp_EnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := writeFile(t, filepath.Join(dir, "first.env"), "TOOL_FIRST=first\nTOOL_BOTH=first\nTOOL_REAL=file\n")
+	second := writeFile(t, filepath.Join(dir, "second.env"), "TOOL_BOTH=second\n")
+
+	tests := []struct {
+		name     string
+		opts     EnvFileOpts
+		args     []string
+		expected map[string]string
+	}{
+		{
+			"flags",
+			EnvFileOpts{},
+			[]string{"--env-file", first, "--env-file", second},
+			map[string]string{"first": "first", "both": "second", "real": "real", "inherited": "inherited"},
+		},
+		{
+			"files before flags",
+			EnvFileOpts{Files: []string{second}},
+			[]string{"--env-file", first},
+			map[string]string{"first": "first", "both": "first", "real": "real", "inherited": "inherited"},
+		},
+		{
+			"override",
+			EnvFileOpts{Files: []string{first}, Override: true},
+			nil,
+			map[string]string{"first": "first", "both": "first", "real": "file", "inherited": "inherited"},
+		},
+		{
+			"clean",
+			EnvFileOpts{Files: []string{first}},
+			[]string{"--clean-env"},
+			map[string]string{"first": "first", "both": "first", "real": "file", "inherited": ""},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			unsetEnv(t, "TOOL_FIRST", "TOOL_BOTH")
+			t.Setenv("TOOL_REAL", "real")
+			t.Setenv("TOOL_INHERITED", "inherited")
+
+			app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}},
+				AppEnvPrefix("TOOL"), AppEnvFiles(test.opts))
+			got := make(map[string]*string)
+			for name := range test.expected {
+				got[name] = new(string)
+				app.StringVar(got[name], name, "", "usage")
+			}
+
+			_, err := runCmd(t, app, test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, expected := range test.expected {
+				if *got[name] != expected {
+					t.Errorf("expected %s '%s' got '%s'", name, expected, *got[name])
+				}
+			}
+		})
+	}
+}
+
+func TestApp_EnvFilesDiscover(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "TOOL_NAME=discovered\n")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	unsetEnv(t, "TOOL_NAME")
+
+	app := New(&cobra.Command{Use: "tool"}, AppEnvPrefix("TOOL"), AppEnvFiles(EnvFileOpts{Discover: true}))
+	var name string
+	app.StringVar(&name, "name", "", "usage")
+	err = app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "discovered" {
+		t.Errorf("expected 'discovered' got '%s'", name)
+	}
+}
+
+func TestApp_EnvFilesMissing(t *testing.T) {
+	app := New(&cobra.Command{Use: "tool"}, AppEnvFiles(EnvFileOpts{Files: []string{filepath.Join(t.TempDir(), "missing.env")}}))
+	err := app.load()
+	if err == nil {
+		t.Error("expected an error for a missing env file")
+	}
+}
+
+func TestApp_EnvFilesCleanConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, filepath.Join(dir, "tool.yaml"), "host: base\nurl: ${TOOL_TEST_REF:-none}\nprofiles:\n  prod:\n    host: prod\n")
+	envFile := writeFile(t, filepath.Join(dir, "tool.env"), "TOOL_TEST_OTHER=file\n")
+	t.Setenv("TOOL_PROFILE", "prod")
+	t.Setenv("TOOL_CONFIG_KEY", "inherited")
+	t.Setenv("TOOL_CONFIG_KEY_FILE", "inherited")
+	t.Setenv("TOOL_TEST_REF", "inherited")
+
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}},
+		AppEnvPrefix("TOOL"), AppEnvFiles(EnvFileOpts{Files: []string{envFile}}), AppProfiles())
+	var host, url string
+	app.StringVar(&host, "host", "", "usage")
+	app.StringVar(&url, "url", "", "usage")
+	app.Init(path, "")
+
+	_, err := runCmd(t, app, "--clean-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "base" || url != "none" {
+		t.Errorf("expected 'base' 'none' got '%s' '%s'", host, url)
+	}
+	for _, name := range []string{"TOOL_PROFILE", "TOOL_CONFIG_KEY", "TOOL_CONFIG_KEY_FILE"} {
+		if val, ok := os.LookupEnv(name); ok {
+			t.Errorf("expected %s to be unset got '%s'", name, val)
+		}
+	}
+}
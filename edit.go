@@ -0,0 +1,244 @@
+package ezcli
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// lookupVar finds the App and variable for a config key
+func (a *App) lookupVar(key string) (*App, *variable, bool) {
+	key = strings.ToLower(key)
+	var found *App
+	var foundVar *variable
+	a.root().visit(func(app *App) {
+		for _, v := range app.vars {
+			if found == nil && strings.ToLower(app.key(v.opts)) == key {
+				found, foundVar = app, v
+			}
+		}
+	})
+	return found, foundVar, found != nil
+}
+
+// lookupVarErr is lookupVar with an error suggesting the closest key
+func (a *App) lookupVarErr(key string) (*App, *variable, error) {
+	app, v, ok := a.lookupVar(key)
+	if ok {
+		return app, v, nil
+	}
+	msg := fmt.Sprintf("unknown config key %q", key)
+	if suggestion := suggestKey(strings.ToLower(key), a.root().knownKeys()); suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return nil, nil, errors.New(msg)
+}
+
+// editFileNote warns that editing a config file loses its comments
+const editFileNote = "The file is rewritten from its values, so comments and formatting are not kept, the original is kept as <path>.bak."
+
+// parseValue parses a value the way the variable's flag would, returning it ready for a config file
+func (a *App) parseValue(v *variable, s string) (any, error) {
+	// A throwaway variable of the same type so the real one is left alone
+	// Options that change how values are parsed are kept
+	check := New(&cobra.Command{Use: "check"})
+	ptr := reflect.New(v.value.Type())
+	check.genericVar(ptr.Interface(), VarName("value"), VarLocal(),
+		VarURLSchemes(v.opts.URLSchemes...), VarTimeLayout(v.opts.TimeLayout), VarDefaultPort(v.opts.DefaultPort))
+	err := check.Cmd.Flags().Set("value", s)
+	if err != nil {
+		return nil, a.redactErr(v.opts, errors.Wrapf(err, "invalid value for %s", v.opts.Name))
+	}
+	return configValue(ptr.Elem().Interface()), nil
+}
+
+// editConfigFile is the config file that config set and unset change
+func (a *App) editConfigFile(cmd *cobra.Command) (string, error) {
+	if path, _ := cmd.Flags().GetString("file"); path != "" {
+		return path, nil
+	}
+	root := a.root()
+	if root.config == nil {
+		return "", errors.New("unable to edit config, Init was not called")
+	}
+	if path, _ := root.configFile(); path != "" {
+		return path, nil
+	}
+	return root.defaultConfigPath(formatYAML), nil
+}
+
+// readEditFile reads a config file without migrating or decrypting it, a missing file is empty
+func readEditFile(path string) (map[string]any, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return make(map[string]any), nil
+	}
+	return readConfigFile(path)
+}
+
+// writeEditFile writes the settings in the file's format
+// The file is rebuilt from its values so comments and formatting aren't kept
+func writeEditFile(path string, settings map[string]any) (string, error) {
+	b, err := encodeConfig(mapSection(settings), formatOf(path))
+	if err != nil {
+		return "", err
+	}
+	return replaceFile(path, b)
+}
+
+// setKey sets a flattened key in nested maps, creating sections as needed
+func setKey(settings map[string]any, key string, value any) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+// unsetKey removes a flattened key from nested maps along with any sections left empty
+func unsetKey(settings map[string]any, key string) bool {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) == 1 {
+		_, ok := settings[key]
+		delete(settings, key)
+		return ok
+	}
+	nested, ok := settings[parts[0]].(map[string]any)
+	if !ok || !unsetKey(nested, parts[1]) {
+		return false
+	}
+	if len(nested) == 0 {
+		delete(settings, parts[0])
+	}
+	return true
+}
+
+func (a *App) configGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the effective value of a config key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, v, err := a.lookupVarErr(args[0])
+			if err != nil {
+				return err
+			}
+			app.RLock()
+			defer app.RUnlock()
+			value := app.redactVar(v.opts, configValue(v.value.Interface()))
+			if s, ok := value.(string); ok {
+				fmt.Fprintln(cmd.OutOrStdout(), s)
+				return nil
+			}
+			s, err := encodeValue(value)
+			if err != nil {
+				return errors.Wrapf(err, "unable to encode %s", args[0])
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), s)
+			return nil
+		},
+	}
+}
+
+func (a *App) configSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a key in the config file, keeping a backup",
+		Long:  "Set a key in the config file, keeping a backup.\n" + editFileNote,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, v, err := a.lookupVarErr(args[0])
+			if err != nil {
+				return err
+			}
+			value, err := app.parseValue(v, args[1])
+			if err != nil {
+				return err
+			}
+			path, err := a.editConfigFile(cmd)
+			if err != nil {
+				return err
+			}
+			settings, err := readEditFile(path)
+			if err != nil {
+				return err
+			}
+
+			key := strings.ToLower(app.key(v.opts))
+			// Keep encrypted values encrypted
+			if old, ok := lookupValue(settings, key); ok && isEncrypted(old) {
+				encryptionKey, err := a.root().encryptionKey()
+				if err != nil {
+					return err
+				}
+				value, err = encryptValue(encryptionKey, args[1])
+				if err != nil {
+					return err
+				}
+			}
+			setKey(settings, key, value)
+
+			backup, err := writeEditFile(path, settings)
+			if err != nil {
+				return err
+			}
+			writeEdited(cmd, "Set", key, path, backup)
+			return nil
+		},
+	}
+	cmd.Flags().String("file", "", "config file to change, defaults to the active config file")
+	return cmd
+}
+
+func (a *App) configUnsetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a key from the config file, keeping a backup",
+		Long:  "Remove a key from the config file, keeping a backup.\n" + editFileNote,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			app, v, err := a.lookupVarErr(args[0])
+			if err != nil {
+				return err
+			}
+			path, err := a.editConfigFile(cmd)
+			if err != nil {
+				return err
+			}
+			settings, err := readEditFile(path)
+			if err != nil {
+				return err
+			}
+
+			key := strings.ToLower(app.key(v.opts))
+			if !unsetKey(settings, key) {
+				return errors.Errorf("%s is not set in %s", key, path)
+			}
+			backup, err := writeEditFile(path, settings)
+			if err != nil {
+				return err
+			}
+			writeEdited(cmd, "Unset", key, path, backup)
+			return nil
+		},
+	}
+	cmd.Flags().String("file", "", "config file to change, defaults to the active config file")
+	return cmd
+}
+
+func writeEdited(cmd *cobra.Command, action, key, path, backup string) {
+	if backup == "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s in %s\n", action, key, path)
+		return
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s %s in %s, backup written to %s\n", action, key, path, backup)
+}
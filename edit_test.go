@@ -0,0 +1,310 @@
+package ezcli
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type editVars struct {
+	name   string
+	port   int
+	wait   time.Duration
+	tags   []string
+	child  string
+	secret string
+}
+
+func editApp(v *editVars, path string) *App {
+	app := New(&cobra.Command{Use: "tool"})
+	app.StringVar(&v.name, "name", "default", "usage")
+	app.IntVar(&v.port, "server.port", 80, "usage")
+	app.DurationVar(&v.wait, "wait", time.Second, "usage")
+	app.Var(&v.tags, "tags", []string(nil), "usage")
+	app.genericVar(&v.secret, VarName("secret"), VarSensitive())
+	child := app.Child(New(&cobra.Command{Use: "child"}))
+	child.StringVar(&v.child, "value", "", "usage")
+	app.Init(path, "")
+	app.AddConfigCommands()
+	return app
+}
+
+func TestApp_ConfigSet(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool."+format)
+			original := map[string]string{
+				formatYAML: "name: file\nextra: kept\n",
+				formatTOML: "name = \"file\"\nextra = \"kept\"\n",
+				formatJSON: `{"name":"file","extra":"kept"}`,
+			}[format]
+			writeFile(t, path, original)
+
+			for _, args := range [][]string{
+				{"server.port", "8080"},
+				{"wait", "1m30s"},
+				{"tags", "a,b"},
+				{"child.value", "nested"},
+				{"NAME", "changed"},
+			} {
+				_, err := runCmd(t, editApp(&editVars{}, path), append([]string{"config", "set"}, args...)...)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			v := &editVars{}
+			err := editApp(v, path).load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.name != "changed" || v.port != 8080 || v.wait != 90*time.Second || strings.Join(v.tags, ",") != "a,b" || v.child != "nested" {
+				t.Errorf("expected values to be set got '%+v'", v)
+			}
+
+			settings, err := readConfigFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if settings["extra"] != "kept" {
+				t.Errorf("expected other keys to be kept got '%v'", settings)
+			}
+			backup, err := os.ReadFile(path + ".bak")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(backup), "changed") {
+				t.Errorf("expected the backup to be from before the last set got '%s'", backup)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigSetNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new", "tool.yaml")
+	out, err := runCmd(t, editApp(&editVars{}, ""), "config", "set", "--file", path, "name", "created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "backup") {
+		t.Errorf("expected no backup for a new file got '%s'", out)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "name: \"created\"\n" {
+		t.Errorf("expected 'name: \"created\"' got '%s'", b)
+	}
+}
+
+func TestApp_ConfigSetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"unknown key", []string{"nmae", "x"}, `unknown config key "nmae", did you mean "name"?`},
+		{"invalid int", []string{"server.port", "eighty"}, "invalid value for server.port"},
+		{"invalid duration", []string{"wait", "soon"}, "invalid value for wait"},
+		{"sensitive", []string{"secret", "hunter2"}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: file\n")
+			_, err := runCmd(t, editApp(&editVars{}, path), append([]string{"config", "set"}, test.args...)...)
+			if test.expected == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+			b, _ := os.ReadFile(path)
+			if string(b) != "name: file\n" {
+				t.Errorf("expected the file to be unchanged got '%s'", b)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigSetEncrypted(t *testing.T) {
+	t.Setenv("TOOL_CONFIG_KEY", testKeyEnv)
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "secret: "+mustEncrypt(t, "old")+"\n")
+	_, err := runCmd(t, editApp(&editVars{}, path), "config", "set", "secret", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "new") || !strings.Contains(string(b), encPrefix) {
+		t.Errorf("expected the value to stay encrypted got '%s'", b)
+	}
+	v := &editVars{}
+	err = editApp(v, path).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.secret != "new" {
+		t.Errorf("expected 'new' got '%s'", v.secret)
+	}
+}
+
+func TestApp_ConfigUnset(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: file\nchild:\n  value: nested\nserver:\n  port: 8080\n  other: kept\n")
+	for _, key := range []string{"child.value", "server.port"} {
+		_, err := runCmd(t, editApp(&editVars{}, path), "config", "unset", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	settings, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := settings["child"]; ok {
+		t.Errorf("expected the empty child section to be removed got '%v'", settings)
+	}
+	server := settings["server"].(map[string]any)
+	if _, ok := server["port"]; ok || server["other"] != "kept" {
+		t.Errorf("expected only server.port to be removed got '%v'", server)
+	}
+
+	_, err = runCmd(t, editApp(&editVars{}, path), "config", "unset", "server.port")
+	if err == nil || !strings.Contains(err.Error(), "server.port is not set in") {
+		t.Errorf("expected an error for a key that isn't set got '%v'", err)
+	}
+}
+
+func TestApp_ConfigGet(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: file\nwait: 2s\ntags: [a, b]\nsecret: hunter2\nchild:\n  value: nested\n")
+	t.Setenv("TOOL_SERVER_PORT", "9090")
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"name", "file"},
+		{"server.port", "9090"},
+		{"wait", "2s"},
+		{"tags", `["a","b"]`},
+		{"child.value", "nested"},
+		{"secret", redacted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			app := New(&cobra.Command{Use: "tool"}, AppEnvPrefix("tool"))
+			v := &editVars{}
+			app.StringVar(&v.name, "name", "default", "usage")
+			app.IntVar(&v.port, "server.port", 80, "usage")
+			app.DurationVar(&v.wait, "wait", time.Second, "usage")
+			app.Var(&v.tags, "tags", []string(nil), "usage")
+			app.genericVar(&v.secret, VarName("secret"), VarSensitive())
+			child := app.Child(New(&cobra.Command{Use: "child"}))
+			child.StringVar(&v.child, "value", "", "usage")
+			app.Init(path, "")
+			app.AddConfigCommands()
+
+			out, err := runCmd(t, app, "config", "get", test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(out, test.expected+"\n") {
+				t.Errorf("expected '%s' got '%s'", test.expected, out)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigSetParseOptions(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		err      string
+	}{
+		{[]string{"when", "2024-01-02"}, `when: "2024-01-02T00:00:00Z"`, ""},
+		{[]string{"server", "example.com"}, `server: "example.com:8080"`, ""},
+		{[]string{"endpoint", "https://example.com"}, `endpoint: "https://example.com"`, ""},
+		{[]string{"endpoint", "ftp://example.com"}, "", "expected a scheme of https"},
+	}
+
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "")
+			s := &struct {
+				When     time.Time `flag:"when" layout:"2006-01-02"`
+				Server   HostPort  `flag:"server" port:"8080"`
+				Endpoint *url.URL  `flag:"endpoint" schemes:"https"`
+			}{}
+			app := New(&cobra.Command{Use: "tool"})
+			app.StructVar(s)
+			app.Init(path, "")
+			app.AddConfigCommands()
+
+			_, err := runCmd(t, app, append([]string{"config", "set"}, test.args...)...)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected '%s' got '%v'", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), test.expected) {
+				t.Errorf("expected '%s' got '%s'", test.expected, b)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigSetInvalidFile(t *testing.T) {
+	portsApp := func(path string) *App {
+		app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}})
+		var ports []int
+		app.Var(&ports, "ports", []int(nil), "usage")
+		app.Init(path, "")
+		app.AddConfigCommands()
+		return app
+	}
+
+	for _, args := range [][]string{{"set", "ports", "1,2"}, {"unset", "ports"}} {
+		t.Run(args[0], func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "ports: [1, x]\n")
+			_, err := runCmd(t, portsApp(path))
+			if err == nil || !strings.Contains(err.Error(), "invalid value for ports") {
+				t.Fatalf("expected the invalid value to stop other commands got '%v'", err)
+			}
+
+			// Config commands warn so they can fix the file
+			out, err := runCmd(t, portsApp(path), append([]string{"config"}, args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, "Warning: invalid value for ports") {
+				t.Errorf("expected a warning got '%s'", out)
+			}
+			err = portsApp(path).load()
+			if err != nil {
+				t.Errorf("expected the file to be fixed got '%v'", err)
+			}
+		})
+	}
+}
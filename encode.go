@@ -0,0 +1,287 @@
+package ezcli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	formatYAML = "yaml"
+	formatTOML = "toml"
+	formatJSON = "json"
+)
+
+// configSection is a group of keys written to a config file
+type configSection struct {
+	name     string
+	usage    string // Short description of a child App's command
+	keys     []configKey
+	sections []*configSection
+}
+
+// configKey is a single value written to a config file
+type configKey struct {
+	name  string
+	usage string
+	value any
+	v     *variable
+}
+
+// section finds or creates the nested section at path
+func (s *configSection) section(path []string) *configSection {
+	if len(path) == 0 {
+		return s
+	}
+	for _, section := range s.sections {
+		if section.name == path[0] {
+			return section.section(path[1:])
+		}
+	}
+	section := &configSection{name: path[0]}
+	s.sections = append(s.sections, section)
+	return section.section(path[1:])
+}
+
+// empty is true when there are no keys in the section or any nested section
+func (s *configSection) empty() bool {
+	if len(s.keys) > 0 {
+		return false
+	}
+	for _, section := range s.sections {
+		if !section.empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultsSection holds the default value of every variable, child Apps get their own section
+func (a *App) defaultsSection() *configSection {
+	s := &configSection{}
+	seen := make(map[string]bool)
+	a.visit(func(app *App) {
+		if app != a {
+			s.section(app.keyPath()).usage = app.Cmd.Short
+		}
+		for _, v := range app.vars {
+			key := app.key(v.opts)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			// Dots in a key are nested sections
+			path := strings.Split(key, ".")
+			section := s.section(path[:len(path)-1])
+			// Sensitive defaults are never written, config files are often committed
+			value := v.opts.DefaultValue
+			if v.opts.Sensitive {
+				value = reflect.Zero(v.value.Type()).Interface()
+			}
+			section.keys = append(section.keys, configKey{
+				name:  path[len(path)-1],
+				usage: v.opts.Usage,
+				value: configValue(value),
+				v:     v,
+			})
+		}
+	})
+	return s
+}
+
+// configValue converts a variable's value to one every config format can hold
+func configValue(v any) any {
+	switch val := v.(type) {
+	case time.Duration:
+		return val.String()
+	case []time.Duration:
+		durations := make([]string, len(val))
+		for i, d := range val {
+			durations[i] = d.String()
+		}
+		return durations
+	case net.IP:
+		if val == nil {
+			return ""
+		}
+		return val.String()
+	case time.Time:
+		return formatTime(time.RFC3339)(val)
+	case *url.URL:
+		return formatURL(val)
+	case *regexp.Regexp:
+		return formatRegexp(val)
+	case *time.Location:
+		return formatLocation(val)
+	case net.IPNet:
+		return formatIPNet(val)
+	case []net.IPNet:
+		cidrs := make([]string, len(val))
+		for i, ipNet := range val {
+			cidrs[i] = formatIPNet(ipNet)
+		}
+		return cidrs
+	}
+
+	if text, ok := customText(v); ok {
+		return text
+	}
+
+	// Write empty lists rather than nothing
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	return v
+}
+
+// formatOf is the config format for a file from its extension
+func formatOf(path string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "yml" {
+		return formatYAML
+	}
+	return ext
+}
+
+// isConfigFormat is true for the formats config files can be written in
+func isConfigFormat(format string) bool {
+	return format == formatYAML || format == formatTOML || format == formatJSON
+}
+
+// encodeConfig writes the section in the given format, comments are not supported by JSON
+func encodeConfig(s *configSection, format string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	var err error
+	switch format {
+	case formatYAML:
+		err = encodeYAML(buf, s, 0)
+	case formatTOML:
+		err = encodeTOML(buf, s, nil)
+	case formatJSON:
+		var b []byte
+		b, err = json.MarshalIndent(jsonSection(s), "", "  ")
+		buf.Write(b)
+		buf.WriteString("\n")
+	default:
+		return nil, errors.Errorf("unsupported config format %s, expected yaml, toml or json", format)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to encode %s config", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeValue writes a value as JSON which is also valid in YAML and TOML
+func encodeValue(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func writeComment(buf *bytes.Buffer, indent, comment string) {
+	if comment == "" {
+		return
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		fmt.Fprintf(buf, "%s# %s\n", indent, line)
+	}
+}
+
+func encodeYAML(buf *bytes.Buffer, s *configSection, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for _, key := range s.keys {
+		value, err := encodeValue(key.value)
+		if err != nil {
+			return errors.Wrapf(err, "unable to encode %s", key.name)
+		}
+		writeComment(buf, indent, key.usage)
+		fmt.Fprintf(buf, "%s%s: %s\n", indent, key.name, value)
+	}
+	for _, section := range s.sections {
+		if section.empty() {
+			continue
+		}
+		fmt.Fprintf(buf, "%s%s:\n", indent, section.name)
+		err := encodeYAML(buf, section, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeTOML(buf *bytes.Buffer, s *configSection, path []string) error {
+	// Keys must come before any nested table
+	if len(path) > 0 && len(s.keys) > 0 {
+		fmt.Fprintf(buf, "\n[%s]\n", strings.Join(path, "."))
+	}
+	for _, key := range s.keys {
+		value, err := encodeTOMLValue(key.value)
+		if err != nil {
+			return errors.Wrapf(err, "unable to encode %s", key.name)
+		}
+		writeComment(buf, "", key.usage)
+		fmt.Fprintf(buf, "%s = %s\n", key.name, value)
+	}
+	for _, section := range s.sections {
+		if section.empty() {
+			continue
+		}
+		err := encodeTOML(buf, section, append(path, section.name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeTOMLValue writes maps as inline tables, every other value is written as JSON
+func encodeTOMLValue(v any) (string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map {
+		return encodeValue(v)
+	}
+	pairs := make([]string, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		// Quoted keys are JSON strings
+		key, err := encodeValue(fmt.Sprint(iter.Key().Interface()))
+		if err != nil {
+			return "", err
+		}
+		value, err := encodeTOMLValue(iter.Value().Interface())
+		if err != nil {
+			return "", err
+		}
+		pairs = append(pairs, key+" = "+value)
+	}
+	sort.Strings(pairs)
+	if len(pairs) == 0 {
+		return "{}", nil
+	}
+	return "{ " + strings.Join(pairs, ", ") + " }", nil
+}
+
+func jsonSection(s *configSection) map[string]any {
+	m := make(map[string]any)
+	for _, key := range s.keys {
+		m[key.name] = key.value
+	}
+	for _, section := range s.sections {
+		if section.empty() {
+			continue
+		}
+		m[section.name] = jsonSection(section)
+	}
+	return m
+}
@@ -0,0 +1,264 @@
+package ezcli
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// encPrefix marks a config value encrypted with AES-GCM, the rest is the base64 nonce and ciphertext
+	encPrefix          = "enc:v1:"
+	configKeyEnvSuffix = "_CONFIG_KEY"
+)
+
+// configKeyEnv is the environment variable holding the base64 encryption key
+// The key can also be read from the file named by <ENV>_FILE
+func (a *App) configKeyEnv() string {
+	return a.appEnv(configKeyEnvSuffix)
+}
+
+// encryptionKey reads the base64 AES key from the environment or the key file
+func (a *App) encryptionKey() ([]byte, error) {
+	env := a.configKeyEnv()
+	encoded := os.Getenv(env)
+	from := env
+	if encoded == "" {
+		path := os.Getenv(env + secretFileEnv)
+		from = env + secretFileEnv
+		if path == "" {
+			path = a.root().opts.keyFile
+			from = path
+		}
+		if path == "" {
+			return nil, errors.Errorf("no encryption key, set %s or %s", env, env+secretFileEnv)
+		}
+		expanded, err := homedir.Expand(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to expand key file %s", path)
+		}
+		b, err := os.ReadFile(expanded)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read encryption key")
+		}
+		encoded = string(b)
+	}
+
+	// Never include the key in errors
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, errors.Errorf("encryption key from %s is not valid base64", from)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, errors.Errorf("encryption key from %s must be 16, 24 or 32 bytes, got %d", from, len(key))
+}
+
+func encryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create nonce")
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptValue opens an encrypted value, errors never include the value
+func decryptValue(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encPrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("unable to decrypt value, is the encryption key correct?")
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid encryption key")
+	}
+	return cipher.NewGCM(block)
+}
+
+func isEncrypted(v any) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, encPrefix)
+}
+
+// decryptConfig decrypts every encrypted value in the settings and records their keys
+// Inactive profiles are skipped, they may be encrypted with another key
+func (a *App) decryptConfig(load *configLoad) error {
+	settings := load.settings
+	var key []byte
+	decrypt := func(name string, v any) (any, error) {
+		if !isEncrypted(v) {
+			return v, nil
+		}
+		if key == nil {
+			var err error
+			key, err = a.encryptionKey()
+			if err != nil {
+				return nil, err
+			}
+		}
+		load.encrypted[name] = true
+		return decryptValue(key, v.(string))
+	}
+
+	for _, name := range flattenKeys(settings, "") {
+		if _, inProfile := profileKey(name); inProfile && a.opts.profiles {
+			continue
+		}
+		parent, last := settings, name
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			var ok bool
+			parent, ok = lookupMap(settings, name[:i])
+			if !ok {
+				continue
+			}
+			last = name[i+1:]
+		}
+
+		var err error
+		switch val := parent[last].(type) {
+		case string:
+			parent[last], err = decrypt(name, val)
+		case []any:
+			decrypted := make([]any, len(val))
+			for i, item := range val {
+				decrypted[i], err = decrypt(name, item)
+				if err != nil {
+					break
+				}
+			}
+			parent[last] = decrypted
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to decrypt %s in %s", name, load.sources[name].Detail)
+		}
+	}
+	return nil
+}
+
+// encrypted is true when a variable's config value, or any value in a map variable, was decrypted
+func (a *App) encrypted(opts *VarOpts) bool {
+	root := a.root()
+	if root.config == nil {
+		return false
+	}
+	key := strings.ToLower(a.key(opts))
+	for name := range root.config.encrypted {
+		if name == key || strings.HasPrefix(name, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// redactErr replaces an error that may contain the value of a sensitive variable or a variable decrypted from the config
+func (a *App) redactErr(opts *VarOpts, err error) error {
+	if a.encrypted(opts) {
+		return errors.Errorf("unable to parse %s from %s", opts.Name, redacted)
+	}
+	return redactErr(opts, err)
+}
+
+// redactVar hides the value of sensitive variables and variables decrypted from the config
+func (a *App) redactVar(opts *VarOpts, v any) any {
+	if a.encrypted(opts) && v != nil && !reflect.ValueOf(v).IsZero() {
+		return redacted
+	}
+	return redact(opts, v)
+}
+
+func (a *App) configEncryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "encrypt <key> [value]",
+		Short: "Print an encrypted config value, the value is read from stdin when not given",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := a.root()
+			if _, ok := root.knownKeys()[strings.ToLower(args[0])]; !ok {
+				return errors.Errorf("unknown config key %q", args[0])
+			}
+			var value string
+			if len(args) > 1 {
+				value = args[1]
+			} else {
+				b, err := io.ReadAll(cmd.InOrStdin())
+				if err != nil {
+					return errors.Wrap(err, "unable to read value")
+				}
+				value = strings.TrimRight(string(b), "\r\n")
+			}
+
+			key, err := root.encryptionKey()
+			if err != nil {
+				return err
+			}
+			encrypted, err := encryptValue(key, value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), encrypted)
+			return nil
+		},
+	}
+}
+
+func (a *App) configDecryptCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "decrypt <key|value>",
+		Short: "Print the decrypted value of a config key or encrypted value",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := a.root()
+			if isEncrypted(args[0]) {
+				key, err := root.encryptionKey()
+				if err != nil {
+					return err
+				}
+				value, err := decryptValue(key, args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), value)
+				return nil
+			}
+
+			name := strings.ToLower(args[0])
+			if root.config == nil || !root.config.encrypted[name] {
+				return errors.Errorf("config key %q is not encrypted", args[0])
+			}
+			root.RLock()
+			defer root.RUnlock()
+			fmt.Fprintln(cmd.OutOrStdout(), root.Viper.Get(name))
+			return nil
+		},
+	}
+}
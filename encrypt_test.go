@@ -0,0 +1,294 @@
+package ezcli
+
+import (
+	"encoding/base64"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	testKey      = []byte("0123456789abcdef0123456789abcdef")
+	testKeyEnv   = base64.StdEncoding.EncodeToString(testKey)
+	otherKeyEnv  = base64.StdEncoding.EncodeToString([]byte("fedcba9876543210"))
+	testPassword = "hunter2"
+)
+
+func mustEncrypt(t *testing.T, value string) string {
+	t.Helper()
+	enc, err := encryptValue(testKey, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestEncryptValue(t *testing.T) {
+	enc := mustEncrypt(t, testPassword)
+	if !strings.HasPrefix(enc, encPrefix) || strings.Contains(enc, testPassword) {
+		t.Errorf("expected an encrypted value got '%s'", enc)
+	}
+	if other := mustEncrypt(t, testPassword); other == enc {
+		t.Error("expected a new nonce for every value")
+	}
+
+	value, err := decryptValue(testKey, enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != testPassword {
+		t.Errorf("expected '%s' got '%s'", testPassword, value)
+	}
+
+	_, err = decryptValue([]byte("fedcba9876543210"), enc)
+	if err == nil {
+		t.Error("expected an error with the wrong key")
+	}
+	_, err = decryptValue(testKey, encPrefix+"bm90IGVub3VnaA")
+	if err == nil {
+		t.Error("expected an error for a malformed value")
+	}
+}
+
+func TestApp_EncryptedConfig(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := writeFile(t, filepath.Join(dir, "key"), testKeyEnv+"\n")
+
+	tests := []struct {
+		name    string
+		env     string
+		envFile string
+		keyFile string
+	}{
+		{"env", testKeyEnv, "", ""},
+		{"env file", "", keyFile, ""},
+		{"key file", "", "", keyFile},
+		{"env over key file", testKeyEnv, "", filepath.Join(dir, "missing")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TOOL_CONFIG_KEY", test.env)
+			t.Setenv("TOOL_CONFIG_KEY_FILE", test.envFile)
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"),
+				"password: "+mustEncrypt(t, testPassword)+"\nhosts: [plain, "+mustEncrypt(t, "secret-host")+"]\n")
+
+			app := New(&cobra.Command{Use: "tool"}, AppConfigKeyFile(test.keyFile))
+			var password string
+			var hosts []string
+			app.StringVar(&password, "password", "", "usage")
+			app.Var(&hosts, "hosts", []string(nil), "usage")
+			app.Init(path, "")
+			app.AddConfigCommands()
+
+			out, err := runCmd(t, app, "config", "show")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if password != testPassword {
+				t.Errorf("expected '%s' got '%s'", testPassword, password)
+			}
+			if strings.Join(hosts, ",") != "plain,secret-host" {
+				t.Errorf("expected 'plain,secret-host' got '%v'", hosts)
+			}
+			if strings.Contains(out, testPassword) || strings.Contains(out, "secret-host") {
+				t.Errorf("expected decrypted values to be redacted in:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestApp_EncryptedConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected string
+	}{
+		{"no key", "", encPrefix + "AAAA", "no encryption key, set TOOL_CONFIG_KEY"},
+		{"invalid key", "not base64!", encPrefix + "AAAA", "not valid base64"},
+		{"short key", base64.StdEncoding.EncodeToString([]byte("short")), encPrefix + "AAAA", "must be 16, 24 or 32 bytes"},
+		{"wrong key", otherKeyEnv, "", "is the encryption key correct?"},
+		{"malformed", testKeyEnv, encPrefix + "!!!", "malformed encrypted value"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TOOL_CONFIG_KEY", test.key)
+			value := test.value
+			if value == "" {
+				value = mustEncrypt(t, testPassword)
+			}
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "password: "+value+"\n")
+			app := New(&cobra.Command{Use: "tool"})
+			var password string
+			app.StringVar(&password, "password", "", "usage")
+			app.Init(path, "")
+
+			err := app.load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			msg := err.Error()
+			if !strings.Contains(msg, test.expected) || !strings.Contains(msg, "password in "+path) {
+				t.Errorf("expected '%s' for password in %s got '%s'", test.expected, path, msg)
+			}
+			if strings.Contains(msg, testPassword) || strings.Contains(msg, value) {
+				t.Errorf("expected no values in '%s'", msg)
+			}
+		})
+	}
+}
+
+func TestApp_EncryptedInactiveProfile(t *testing.T) {
+	t.Setenv("TOOL_CONFIG_KEY", testKeyEnv)
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), `
+password: `+mustEncrypt(t, testPassword)+`
+profiles:
+  prod:
+    password: `+encPrefix+`encryptedWithTheProdKey
+`)
+	app := New(&cobra.Command{Use: "tool"}, AppProfiles())
+	var password string
+	app.StringVar(&password, "password", "", "usage")
+	app.Init(path, "")
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if password != testPassword {
+		t.Errorf("expected '%s' got '%s'", testPassword, password)
+	}
+}
+
+func TestApp_ConfigEncryptDecrypt(t *testing.T) {
+	t.Setenv("TOOL_CONFIG_KEY", testKeyEnv)
+
+	newApp := func(path string) *App {
+		app := New(&cobra.Command{Use: "tool"})
+		var password string
+		app.StringVar(&password, "password", "", "usage")
+		app.Init(path, "")
+		app.AddConfigCommands()
+		return app
+	}
+
+	out, err := runCmd(t, newApp(""), "config", "encrypt", "password", testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := strings.TrimSpace(out)
+	if !strings.HasPrefix(enc, encPrefix) {
+		t.Fatalf("expected an encrypted value got '%s'", out)
+	}
+
+	// From stdin to keep the value out of shell history
+	app := newApp("")
+	app.Cmd.SetIn(strings.NewReader(testPassword + "\n"))
+	out, err = runCmd(t, app, "config", "encrypt", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromStdin := strings.TrimSpace(out)
+	value, err := decryptValue(testKey, fromStdin)
+	if err != nil || value != testPassword {
+		t.Errorf("expected '%s' from stdin got '%s' %v", testPassword, value, err)
+	}
+
+	_, err = runCmd(t, newApp(""), "config", "encrypt", "pasword", testPassword)
+	if err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+
+	out, err = runCmd(t, newApp(""), "config", "decrypt", enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out) != testPassword {
+		t.Errorf("expected '%s' got '%s'", testPassword, out)
+	}
+
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "password: "+enc+"\n")
+	out, err = runCmd(t, newApp(path), "config", "decrypt", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(out, testPassword+"\n") {
+		t.Errorf("expected '%s' got '%s'", testPassword, out)
+	}
+
+	path = writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "password: plain\n")
+	_, err = runCmd(t, newApp(path), "config", "decrypt", "password")
+	if err == nil {
+		t.Error("expected an error for a value that isn't encrypted")
+	}
+}
+
+func TestApp_EncryptedParseError(t *testing.T) {
+	t.Setenv("TOOL_CONFIG_KEY", testKeyEnv)
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "endpoint: "+mustEncrypt(t, testPassword)+"\nports: ["+mustEncrypt(t, testPassword)+"]\n")
+
+	for _, name := range []string{"endpoint", "ports"} {
+		t.Run(name, func(t *testing.T) {
+			app := New(&cobra.Command{Use: "tool"})
+			if name == "endpoint" {
+				var endpoint *url.URL
+				app.Var(&endpoint, "endpoint", (*url.URL)(nil), "")
+			} else {
+				var ports []int
+				app.Var(&ports, "ports", []int(nil), "")
+			}
+			app.Init(path, "")
+
+			err := app.load()
+			expected := "unable to parse " + name + " from " + redacted
+			if err == nil || err.Error() != expected {
+				t.Errorf("expected '%s' got '%v'", expected, err)
+			}
+		})
+	}
+}
+
+func TestApp_EncryptedMap(t *testing.T) {
+	t.Setenv("TOOL_CONFIG_KEY", testKeyEnv)
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"),
+		"labels:\n  env: prod\n  token: "+mustEncrypt(t, testPassword)+"\nlimits:\n  token: "+mustEncrypt(t, testPassword)+"\n")
+
+	t.Run("show", func(t *testing.T) {
+		app := New(&cobra.Command{Use: "tool"})
+		var labels map[string]string
+		app.Var(&labels, "labels", map[string]string(nil), "usage")
+		app.Init(path, "")
+		app.AddConfigCommands()
+
+		for _, args := range [][]string{{"config", "show"}, {"config", "get", "labels"}} {
+			out, err := runCmd(t, app, args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if labels["token"] != testPassword {
+				t.Errorf("expected '%s' got '%s'", testPassword, labels["token"])
+			}
+			if strings.Contains(out, testPassword) {
+				t.Errorf("expected decrypted values to be redacted in:\n%s", out)
+			}
+		}
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		app := New(&cobra.Command{Use: "tool"})
+		var limits map[string]int
+		app.Var(&limits, "limits", map[string]int(nil), "usage")
+		app.Init(path, "")
+
+		err := app.load()
+		expected := "unable to parse limits from " + redacted
+		if err == nil || err.Error() != expected {
+			t.Errorf("expected '%s' got '%v'", expected, err)
+		}
+	})
+}
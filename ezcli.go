@@ -3,36 +3,83 @@ package ezcli
 import (
 	"fmt"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type App struct {
 	Cmd           *cobra.Command
 	Viper         *viper.Viper
-	postLoadFuncs []func()
+	opts          *AppOpts
+	config        *configOpts
+	envFiles      *envFiles
+	profile       string      // Profile from the --profile flag
+	vars          []*variable // Every registered variable
+	sources       map[string]Source
+	postLoadFuncs []postLoad
+	migrations    []migration    // Config file migrations, only the root App's are used
+	configCmd     *cobra.Command // From AddConfigCommands, its commands run even when the config fails to load
+	parent        *App
 	children      []*App
+	mu            sync.RWMutex // Guards variables while they are set, only the root App's is used
 }
 
-func New(cmd *cobra.Command) *App {
+// variable is a registered option and the value it sets
+type variable struct {
+	opts  *VarOpts
+	flag  *pflag.Flag
+	value reflect.Value
+}
+
+// postLoad sets a variable from its resolved value
+// fn parses the value and returns a function that sets it, so every value can be checked before any are set
+type postLoad struct {
+	opts *VarOpts // Options of the variable the function sets
+	fn   func() (func(), error)
+}
+
+func New(cmd *cobra.Command, optFns ...appOptFn) *App {
+	opts := &AppOpts{}
+	for _, optFn := range optFns {
+		optFn(opts)
+	}
+
 	a := &App{
 		Cmd:           cmd,
 		Viper:         viper.New(),
-		postLoadFuncs: make([]func(), 0),
+		opts:          opts,
+		sources:       make(map[string]Source),
+		postLoadFuncs: make([]postLoad, 0),
 		children:      make([]*App, 0),
 	}
+	if opts.useConfig {
+		a.Init("", cmd.Name())
+	}
+	if opts.envFiles != nil {
+		a.addEnvFileFlags(*opts.envFiles)
+	}
+	if opts.profiles {
+		a.addProfileFlag()
+	}
 
 	return a
 }
 
 func (a *App) Child(child *App) *App {
 	a.Cmd.AddCommand(child.Cmd)
+	child.parent = a
 	a.children = append(a.children, child)
 	return child
 }
@@ -57,7 +104,107 @@ func (a *App) Var(variable any, name string, value any, usage string) {
 	a.genericVar(variable, VarName(name), VarDefaultValue(value), VarUsage(usage))
 }
 
-func (a *App) genericVar(v any, optFns ...varOptFn) {
+// root is the top most App that config is loaded for
+func (a *App) root() *App {
+	for a.parent != nil {
+		a = a.parent
+	}
+	return a
+}
+
+// RLock locks every variable for reading, a config reload will wait until RUnlock is called
+func (a *App) RLock() {
+	a.root().mu.RLock()
+}
+
+// RUnlock undoes a single RLock call
+func (a *App) RUnlock() {
+	a.root().mu.RUnlock()
+}
+
+// onLoad adds a function to parse a variable once its value is resolved, returning a function that sets it
+// Values that fail to parse are returned as errors from loading
+func (a *App) onLoad(opts *VarOpts, fn func() (func(), error)) {
+	a.postLoadFuncs = append(a.postLoadFuncs, postLoad{opts: opts, fn: fn})
+}
+
+// setter is a post-load function that can't fail
+func setter(set func()) func() (func(), error) {
+	return func() (func(), error) {
+		return set, nil
+	}
+}
+
+// key is the config key for a variable
+// Variables of child Apps are nested under each command name, eg: foo.bar.name
+func (a *App) key(opts *VarOpts) string {
+	if opts.SharedKey {
+		return opts.Name
+	}
+	return strings.Join(append(a.keyPath(), opts.Name), ".")
+}
+
+// keyPath is the chain of command names below the root App, the config section for the App
+func (a *App) keyPath() []string {
+	path := make([]string, 0)
+	for app := a; app.parent != nil; app = app.parent {
+		path = append([]string{app.Cmd.Name()}, path...)
+	}
+	return path
+}
+
+// envPrefix is the App's environment variable prefix, inherited from its parents if unset
+func (a *App) envPrefix() string {
+	for app := a; app != nil; app = app.parent {
+		if app.opts.envPrefix != "" {
+			return strings.TrimSuffix(app.opts.envPrefix, "_")
+		}
+	}
+	return ""
+}
+
+// env is the environment variable for a variable, an explicit name takes precedence over the prefix
+func (a *App) env(opts *VarOpts) string {
+	if opts.Env != "" {
+		return opts.Env
+	}
+	prefix := a.envPrefix()
+	if prefix == "" {
+		if opts.envFromName {
+			return strings.ToUpper(opts.Name)
+		}
+		return ""
+	}
+	return envName(prefix + "_" + a.key(opts))
+}
+
+// appEnv is an environment variable for the whole App, <PREFIX><suffix> or <COMMAND><suffix> without a prefix
+func (a *App) appEnv(suffix string) string {
+	name := a.envPrefix()
+	if name == "" {
+		name = a.Cmd.Name()
+	}
+	return envName(name) + suffix
+}
+
+// envName normalises a key to an environment variable name
+func envName(key string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// bind maps every variable's config key to its flag and environment variable
+// Keys are only known once the App has been added to its parent
+func (a *App) bind() {
+	for _, v := range a.vars {
+		key := a.key(v.opts)
+		a.Viper.BindPFlag(key, v.flag)
+		if env := a.env(v.opts); env != "" {
+			a.Viper.BindEnv(key, env)
+		}
+	}
+}
+
+func (a *App) genericVar(v any, optFns ...varOptFn) *VarOpts {
 	// Setup our variable options
 	opts := defaultVarOpts()
 	for _, optFn := range optFns {
@@ -69,7 +216,7 @@ func (a *App) genericVar(v any, optFns ...varOptFn) {
 	// Get the appropriate cobra flagSet for use later
 	// Local flags only apply to this command
 	// Persistent flags apply to all sub-commands
-	flagSet := a.Cmd.LocalFlags()
+	flagSet := a.Cmd.Flags()
 	if opts.Persistent {
 		flagSet = a.Cmd.PersistentFlags()
 	}
@@ -81,8 +228,8 @@ func (a *App) genericVar(v any, optFns ...varOptFn) {
 	}
 	// Get the value of the pointer
 	elem := typeOf.Elem()
-	// TODO should we panic if it's a pointer here?
-	if elem.Kind() == reflect.Pointer {
+	// Only pointers to types that are always used as pointers, eg: *url.URL
+	if elem.Kind() == reflect.Pointer && !valueTypes[elem] {
 		panic("unable to service a pointer to a pointer")
 	}
 	// Get the value out so we can set it later
@@ -93,81 +240,148 @@ func (a *App) genericVar(v any, optFns ...varOptFn) {
 		opts.DefaultValue = reflect.Zero(elem).Interface()
 	}
 
-	var postLoadFunc func()
+	var postLoadFunc func() (func(), error)
 
 	// Set the flag for the kind of data
 	switch elem.String() {
-	// TODO Could we and should we allow type aliases from users?
 	case "bool":
 		flagSet.BoolVar(v.(*bool), opts.Name, opts.DefaultValue.(bool), opts.Usage)
-		postLoadFunc = func() {
-			val.SetBool(a.Viper.GetBool(opts.Name))
-		}
+		postLoadFunc = setter(func() {
+			val.SetBool(a.Viper.GetBool(a.key(opts)))
+		})
 
 	case "int":
 		flagSet.IntVar(v.(*int), opts.Name, opts.DefaultValue.(int), opts.Usage)
-		postLoadFunc = func() { val.SetInt(a.Viper.GetInt64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetInt(a.Viper.GetInt64(a.key(opts))) })
 
 	case "int8":
 		flagSet.Int8Var(v.(*int8), opts.Name, opts.DefaultValue.(int8), opts.Usage)
-		postLoadFunc = func() { val.SetInt(a.Viper.GetInt64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetInt(a.Viper.GetInt64(a.key(opts))) })
 
 	case "int16":
 		flagSet.Int16Var(v.(*int16), opts.Name, opts.DefaultValue.(int16), opts.Usage)
-		postLoadFunc = func() { val.SetInt(a.Viper.GetInt64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetInt(a.Viper.GetInt64(a.key(opts))) })
 
 	case "int32":
 		flagSet.Int32Var(v.(*int32), opts.Name, opts.DefaultValue.(int32), opts.Usage)
-		postLoadFunc = func() { val.SetInt(a.Viper.GetInt64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetInt(a.Viper.GetInt64(a.key(opts))) })
 
 	case "int64":
 		flagSet.Int64Var(v.(*int64), opts.Name, opts.DefaultValue.(int64), opts.Usage)
-		postLoadFunc = func() { val.SetInt(a.Viper.GetInt64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetInt(a.Viper.GetInt64(a.key(opts))) })
 
 	case "uint":
 		flagSet.UintVar(v.(*uint), opts.Name, opts.DefaultValue.(uint), opts.Usage)
-		postLoadFunc = func() { val.SetUint(a.Viper.GetUint64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetUint(a.Viper.GetUint64(a.key(opts))) })
 
 	case "uint8":
 		flagSet.Uint8Var(v.(*uint8), opts.Name, opts.DefaultValue.(uint8), opts.Usage)
-		postLoadFunc = func() { val.SetUint(a.Viper.GetUint64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetUint(a.Viper.GetUint64(a.key(opts))) })
 
 	case "uint16":
 		flagSet.Uint16Var(v.(*uint16), opts.Name, opts.DefaultValue.(uint16), opts.Usage)
-		postLoadFunc = func() { val.SetUint(a.Viper.GetUint64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetUint(a.Viper.GetUint64(a.key(opts))) })
 
 	case "uint32":
 		flagSet.Uint32Var(v.(*uint32), opts.Name, opts.DefaultValue.(uint32), opts.Usage)
-		postLoadFunc = func() { val.SetUint(a.Viper.GetUint64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetUint(a.Viper.GetUint64(a.key(opts))) })
 
 	case "uint64":
 		flagSet.Uint64Var(v.(*uint64), opts.Name, opts.DefaultValue.(uint64), opts.Usage)
-		postLoadFunc = func() { val.SetUint(a.Viper.GetUint64(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetUint(a.Viper.GetUint64(a.key(opts))) })
+
+	case "float32":
+		flagSet.Float32Var(v.(*float32), opts.Name, opts.DefaultValue.(float32), opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseFloat32)
+
+	case "float64":
+		flagSet.Float64Var(v.(*float64), opts.Name, opts.DefaultValue.(float64), opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseFloat64)
 
 	case "net.IP":
 		flagSet.IPVar(v.(*net.IP), opts.Name, opts.DefaultValue.(net.IP), opts.Usage)
-		postLoadFunc = func() { val.Set(reflect.ValueOf(net.ParseIP(a.Viper.GetString(opts.Name)))) }
+		postLoadFunc = loadScalar(a, opts, val, parseIP)
+
+	case "net.IPNet":
+		flagSet.Var(newScalarValue(v.(*net.IPNet), opts.DefaultValue.(net.IPNet), "ipNet", parseIPNet, formatIPNet), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseIPNet)
+
+	case "[]net.IPNet":
+		flagSet.Var(newSliceValue(v.(*[]net.IPNet), opts.DefaultValue.([]net.IPNet), "ipNetSlice", parseIPNet, formatIPNet), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseIPNet)
+
+	case "netip.Addr":
+		flagSet.Var(newScalarValue(v.(*netip.Addr), opts.DefaultValue.(netip.Addr), "addr", parseAddr, formatText[netip.Addr]), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseAddr)
+
+	case "[]netip.Addr":
+		flagSet.Var(newSliceValue(v.(*[]netip.Addr), opts.DefaultValue.([]netip.Addr), "addrSlice", parseAddr, formatText[netip.Addr]), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseAddr)
+
+	case "netip.Prefix":
+		flagSet.Var(newScalarValue(v.(*netip.Prefix), opts.DefaultValue.(netip.Prefix), "prefix", parsePrefix, formatText[netip.Prefix]), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parsePrefix)
+
+	case "[]netip.Prefix":
+		flagSet.Var(newSliceValue(v.(*[]netip.Prefix), opts.DefaultValue.([]netip.Prefix), "prefixSlice", parsePrefix, formatText[netip.Prefix]), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parsePrefix)
+
+	case "netip.AddrPort":
+		flagSet.Var(newScalarValue(v.(*netip.AddrPort), opts.DefaultValue.(netip.AddrPort), "addrPort", parseAddrPort, formatText[netip.AddrPort]), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseAddrPort)
+
+	case "[]netip.AddrPort":
+		flagSet.Var(newSliceValue(v.(*[]netip.AddrPort), opts.DefaultValue.([]netip.AddrPort), "addrPortSlice", parseAddrPort, formatText[netip.AddrPort]), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseAddrPort)
+
+	case "*url.URL":
+		parse := parseURL(opts.URLSchemes)
+		flagSet.Var(newScalarValue(v.(**url.URL), opts.DefaultValue.(*url.URL), "url", parse, formatURL), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parse)
+
+	case "*regexp.Regexp":
+		flagSet.Var(newScalarValue(v.(**regexp.Regexp), opts.DefaultValue.(*regexp.Regexp), "regexp", parseRegexp, formatRegexp), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseRegexp)
+
+	case "time.Time":
+		parse := parseTime(opts.TimeLayout)
+		flagSet.Var(newScalarValue(v.(*time.Time), opts.DefaultValue.(time.Time), "time", parse, formatTime(opts.TimeLayout)), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parse)
+
+	case "*time.Location":
+		flagSet.Var(newScalarValue(v.(**time.Location), opts.DefaultValue.(*time.Location), "location", parseLocation, formatLocation), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parseLocation)
+
+	case "ezcli.HostPort":
+		parse := parseHostPort(opts.DefaultPort)
+		flagSet.Var(newScalarValue(v.(*HostPort), opts.DefaultValue.(HostPort), "hostPort", parse, formatHostPort), opts.Name, opts.Usage)
+		postLoadFunc = loadScalar(a, opts, val, parse)
+
+	case "[]ezcli.HostPort":
+		parse := parseHostPort(opts.DefaultPort)
+		flagSet.Var(newSliceValue(v.(*[]HostPort), opts.DefaultValue.([]HostPort), "hostPortSlice", parse, formatHostPort), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parse)
 
 	case "string":
 		flagSet.StringVar(v.(*string), opts.Name, opts.DefaultValue.(string), opts.Usage)
-		postLoadFunc = func() { val.SetString(a.Viper.GetString(opts.Name)) }
+		postLoadFunc = setter(func() { val.SetString(a.Viper.GetString(a.key(opts))) })
 
 	case "[]string":
 		flagSet.StringSliceVar(v.(*[]string), opts.Name, opts.DefaultValue.([]string), opts.Usage)
-		postLoadFunc = func() { val.Set(reflect.ValueOf(a.Viper.GetStringSlice(opts.Name))) }
+		postLoadFunc = setter(func() { val.Set(reflect.ValueOf(a.Viper.GetStringSlice(a.key(opts)))) })
 
 	case "time.Duration":
 		flagSet.DurationVar(v.(*time.Duration), opts.Name, opts.DefaultValue.(time.Duration), opts.Usage)
-		postLoadFunc = func() { val.Set(reflect.ValueOf(a.Viper.GetDuration(opts.Name))) }
+		postLoadFunc = setter(func() { val.Set(reflect.ValueOf(a.Viper.GetDuration(a.key(opts)))) })
 
 	case "[]time.Duration":
 		flagSet.DurationSliceVar(v.(*[]time.Duration), opts.Name, opts.DefaultValue.([]time.Duration), opts.Usage)
-		postLoadFunc = func() {
+		postLoadFunc = func() (func(), error) {
 			// Check for flag / env values - they're strings
-			durationStrings := a.Viper.GetString(opts.Name)
+			durationStrings := a.Viper.GetString(a.key(opts))
 			// If we didn't get anything, check it wasn't provided as a slice
 			if durationStrings == "" {
-				durationStringSlice := a.Viper.GetStringSlice(opts.Name)
+				durationStringSlice := a.Viper.GetStringSlice(a.key(opts))
 				if len(durationStringSlice) > 0 {
 					// Join to fit the interface
 					// This can be optimized
@@ -177,81 +391,225 @@ func (a *App) genericVar(v any, optFns ...varOptFn) {
 
 			durations, err := parseDurationSlice(durationStrings)
 			if err != nil {
-				panic(err)
+				return nil, a.redactErr(opts, errors.Wrapf(err, "invalid value for %s", opts.Name))
 			}
-			val.Set(reflect.ValueOf(durations))
+			return func() { val.Set(reflect.ValueOf(durations)) }, nil
 		}
 
+	case "[]int":
+		flagSet.Var(newSliceValue(v.(*[]int), opts.DefaultValue.([]int), "intSlice", strconv.Atoi, strconv.Itoa), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, strconv.Atoi)
+
+	case "[]int64":
+		flagSet.Var(newSliceValue(v.(*[]int64), opts.DefaultValue.([]int64), "int64Slice", parseInt64, formatInt64), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseInt64)
+
+	case "[]uint":
+		flagSet.Var(newSliceValue(v.(*[]uint), opts.DefaultValue.([]uint), "uintSlice", parseUint, formatUint), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseUint)
+
+	case "[]bool":
+		flagSet.Var(newSliceValue(v.(*[]bool), opts.DefaultValue.([]bool), "boolSlice", strconv.ParseBool, strconv.FormatBool), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, strconv.ParseBool)
+
+	case "[]net.IP":
+		flagSet.Var(newSliceValue(v.(*[]net.IP), opts.DefaultValue.([]net.IP), "ipSlice", parseIP, formatIP), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseIP)
+
+	case "map[string]string":
+		flagSet.StringToStringVar(v.(*map[string]string), opts.Name, opts.DefaultValue.(map[string]string), opts.Usage)
+		postLoadFunc = loadMap(a, opts, val, parseString)
+
+	case "map[string]int":
+		flagSet.StringToIntVar(v.(*map[string]int), opts.Name, opts.DefaultValue.(map[string]int), opts.Usage)
+		postLoadFunc = loadMap(a, opts, val, strconv.Atoi)
+
+	case "map[string]bool":
+		flagSet.Var(newMapValue(v.(*map[string]bool), opts.DefaultValue.(map[string]bool), "stringToBool", strconv.ParseBool, strconv.FormatBool), opts.Name, opts.Usage)
+		postLoadFunc = loadMap(a, opts, val, strconv.ParseBool)
+
+	case "[]float32":
+		flagSet.Var(newSliceValue(v.(*[]float32), opts.DefaultValue.([]float32), "float32Slice", parseFloat32, formatFloat32), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseFloat32)
+
+	case "[]float64":
+		flagSet.Var(newSliceValue(v.(*[]float64), opts.DefaultValue.([]float64), "float64Slice", parseFloat64, formatFloat64), opts.Name, opts.Usage)
+		postLoadFunc = loadSlice(a, opts, val, parseFloat64)
+
 	default:
-		// TODO how to handle aliases of base types?
-		// Should we even do this?
-		panic(fmt.Sprintf("unable to use variable type %s", elem))
+		// Types that parse themselves and named types over the kinds above
+		value, ok := typedValue(v)
+		if !ok {
+			panic(fmt.Sprintf("unable to use variable type %s", elem))
+		}
+		val.Set(reflect.ValueOf(opts.DefaultValue))
+		flagSet.Var(value, opts.Name, opts.Usage)
+		postLoadFunc = loadValue(a, opts, val, flagSet)
 	}
 
 	// Prepare our post load function
-	a.postLoadFuncs = append(a.postLoadFuncs, postLoadFunc)
+	a.onLoad(opts, postLoadFunc)
+	flag := flagSet.Lookup(opts.Name)
+	// Keep sensitive defaults out of help
+	if redact(opts, opts.DefaultValue) == redacted {
+		flag.DefValue = redacted
+	}
+	a.vars = append(a.vars, &variable{opts: opts, flag: flag, value: val})
 
 	// Bind the cobra flag to Viper for configuration file and environment mapping
-	a.Viper.BindPFlag(opts.Name, flagSet.Lookup(opts.Name))
+	a.Viper.BindPFlag(opts.Name, flag)
 	if opts.Env != "" {
 		a.Viper.BindEnv(opts.Name, opts.Env)
 	}
+	return opts
 }
 
-func (a *App) Init(pathToConfigFile, configName string) {
-	// TODO determine if we need to find/use a configuration file
-	// Set our state after the command executes
-	a.initConfig(pathToConfigFile, configName)
+// Init sets up the App to read config files when it is executed
+// The main config file is taken from the --config flag, the <APP>_CONFIG environment variable,
+// the provided path, then the first file named configName found in the working directory,
+// $XDG_CONFIG_HOME/<app>, $HOME and /etc/<app>
+// Any layers are deep-merged in order beneath the main config file
+func (a *App) Init(pathToConfigFile, configName string, layers ...ConfigLayer) {
+	// Calling Init again replaces where we look for config
+	if a.config != nil {
+		a.config.path = pathToConfigFile
+		a.config.name = configName
+		a.config.layers = layers
+		return
+	}
+	a.config = &configOpts{
+		path:   pathToConfigFile,
+		name:   configName,
+		layers: layers,
+	}
+	a.Cmd.PersistentFlags().StringVar(&a.config.flagPath, configFlag, "",
+		fmt.Sprintf("config file path, can be set with %s", a.configEnv()))
 }
 
-func (a *App) InitNoConfig() {
+// InitNoConfig sets every variable from its flag, environment variable or default without reading config files
+func (a *App) InitNoConfig() error {
+	err := a.loadEnv()
+	if err != nil {
+		return err
+	}
 	// Set our state after the command executes
-	a.init()
-}
-
-func (a *App) initConfig(pathToConfigFile, configName string) func() {
-	return func() {
-		if pathToConfigFile != "" {
-			// TODO
-			// This is where we'd look for a flag with the config path
-			a.Viper.SetConfigFile(pathToConfigFile)
-		} else {
-			// Find home directory for config
-			home, err := homedir.Dir()
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
+	return a.init()
+}
 
-			// Search config in home directory with name ".nrf-go" (without extension).
-			a.Viper.AddConfigPath(home)
-			a.Viper.SetConfigName(configName)
-		}
+// loadEnv sets environment variables from dotenv files and reads any secret files
+func (a *App) loadEnv() error {
+	err := a.loadEnvFiles()
+	if err != nil {
+		return err
+	}
+	return a.loadSecretFiles()
+}
 
-		// If a config file is found, read it in.
-		err := a.Viper.ReadInConfig()
-		if err == nil {
-			// TODO use log
-			fmt.Println("Using config file:", a.Viper.ConfigFileUsed())
+// load reads any config file then sets every variable from its resolved value
+func (a *App) load() error {
+	// Env files may set where the config file is
+	err := a.loadEnvFiles()
+	if err != nil {
+		return err
+	}
+	var loaded *configLoad
+	if a.config != nil {
+		loaded, err = a.readConfig()
+		if err != nil {
+			return err
 		}
+	}
+	err = a.loadSecretFiles()
+	if err != nil {
+		return err
+	}
+	root := a.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if loaded != nil {
+		err = a.applyConfig(loaded)
+		if err != nil {
+			return err
+		}
+	}
+	return a.init()
+}
 
-		a.init()
+// loadErr names the active profile in errors from loading
+func (a *App) loadErr(err error) error {
+	if profile := a.activeProfile(); profile != "" && err != nil {
+		return errors.Wrapf(err, "profile %s", profile)
 	}
+	return err
 }
 
-func (a *App) init() {
+func (a *App) init() error {
+	a.bind()
+	a.resolveSources()
 	// Run our post load functions
-	for _, fn := range a.postLoadFuncs {
-		fn()
+	for _, postLoad := range a.postLoadFuncs {
+		set, err := postLoad.fn()
+		if err != nil {
+			return err
+		}
+		set()
 	}
 	// Run every childs post load
 	for _, child := range a.children {
-		child.init()
+		err := child.init()
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// loadCmd loads the App before cmd runs
+// Config commands only warn when the config fails to load, so they can be used to fix it
+func (a *App) loadCmd(cmd *cobra.Command) error {
+	err := a.loadErr(a.load())
+	if err != nil && a.isConfigCmd(cmd) {
+		fmt.Fprintln(a.Cmd.ErrOrStderr(), "Warning:", err)
+		return nil
+	}
+	return err
+}
+
+// isConfigCmd is true when cmd is one of the commands added by AddConfigCommands
+func (a *App) isConfigCmd(cmd *cobra.Command) bool {
+	found := false
+	a.root().visit(func(app *App) {
+		for c := cmd; c != nil && app.configCmd != nil; c = c.Parent() {
+			if c == app.configCmd {
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// executingCmd is the command cobra is running, it is known once the command's flags are parsed
+func executingCmd(cmd *cobra.Command) *cobra.Command {
+	if cmd.CalledAs() != "" {
+		return cmd
+	}
+	for _, child := range cmd.Commands() {
+		if found := executingCmd(child); found != nil {
+			return found
+		}
+	}
+	return nil
 }
 
 func (a *App) Execute() error {
-	// Queue up our initialize functions to run when cobra starts
-	cobra.OnInitialize(a.init)
+	// Queue up our configuration loading to run when cobra starts
+	cobra.OnInitialize(func() {
+		err := a.loadCmd(executingCmd(a.Cmd))
+		if err != nil {
+			fmt.Fprintln(a.Cmd.ErrOrStderr(), "Error:", err)
+			os.Exit(1)
+		}
+	})
+	a.redactFlagErrors()
 	return a.Cmd.Execute()
 }
@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -125,6 +130,10 @@ func TestApp_FromFlag(t *testing.T) {
 	doGVarFlagTest[uint32](t, "8123567", 8123567)
 	doGVarFlagTest[uint64](t, "10987654321", 10987654321)
 
+	doGVarFlagTest[float32](t, "0.25", 0.25)
+	doGVarFlagTest[float64](t, "3.14159", 3.14159)
+	doGVarFlagTest[float64](t, "1e-10", 1e-10)
+
 	doGVarFlagTest[time.Duration](t, "5s", 5*time.Second)
 	doGVarFlagTest[net.IP](t, "127.0.0.1", net.IPv4(127, 0, 0, 1))
 	doGVarFlagTest[net.IP](t, "ff02::1", net.IPv6linklocalallnodes)
@@ -132,6 +141,32 @@ func TestApp_FromFlag(t *testing.T) {
 	// Slices
 	doGVarFlagTest[[]string](t, "s1,s2", []string{"s1", "s2"})
 	doGVarFlagTest[[]time.Duration](t, "5s,2h", []time.Duration{5 * time.Second, 2 * time.Hour})
+	doGVarFlagTest[[]float32](t, "0.5,1.25", []float32{0.5, 1.25})
+	doGVarFlagTest[[]float64](t, "0.5,1e-10,-2", []float64{0.5, 1e-10, -2})
+	doGVarFlagTest[[]int](t, "80,-443", []int{80, -443})
+	doGVarFlagTest[[]int64](t, "1234567890123,-1", []int64{1234567890123, -1})
+	doGVarFlagTest[[]uint](t, "53,853", []uint{53, 853})
+	doGVarFlagTest[[]bool](t, "true,false,1", []bool{true, false, true})
+	doGVarFlagTest[[]net.IP](t, "1.1.1.1,ff02::1", []net.IP{net.IPv4(1, 1, 1, 1), net.IPv6linklocalallnodes})
+	doGVarFlagTest[netip.Addr](t, "2001:db8::1", netip.MustParseAddr("2001:db8::1"))
+	doGVarFlagTest[netip.Prefix](t, "10.0.0.0/8", netip.MustParsePrefix("10.0.0.0/8"))
+	doGVarFlagTest[netip.AddrPort](t, "[::1]:8080", netip.MustParseAddrPort("[::1]:8080"))
+	doGVarFlagTest[net.IPNet](t, "10.1.2.3/16", mustCIDR("10.1.0.0/16"))
+	doGVarFlagTest[HostPort](t, "example.com:443", HostPort{"example.com", 443})
+	doGVarFlagTest[[]netip.Addr](t, "1.1.1.1,::1", []netip.Addr{netip.MustParseAddr("1.1.1.1"), netip.MustParseAddr("::1")})
+	doGVarFlagTest[[]netip.Prefix](t, "10.0.0.0/8,fd00::/8", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8")})
+	doGVarFlagTest[[]netip.AddrPort](t, "1.1.1.1:53", []netip.AddrPort{netip.MustParseAddrPort("1.1.1.1:53")})
+	doGVarFlagTest[[]net.IPNet](t, "10.0.0.0/8,fd00::/8", []net.IPNet{mustCIDR("10.0.0.0/8"), mustCIDR("fd00::/8")})
+	doGVarFlagTest[[]HostPort](t, "a:1,[::1]:2", []HostPort{{"a", 1}, {"::1", 2}})
+	doGVarFlagTest[*url.URL](t, "https://example.com/path?q=1", mustURL("https://example.com/path?q=1"))
+	doGVarFlagTest[*regexp.Regexp](t, "^a+$", regexp.MustCompile("^a+$"))
+	doGVarFlagTest[time.Time](t, "2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	doGVarFlagTest[*time.Location](t, "UTC", time.UTC)
+
+	// Maps
+	doGVarFlagTest[map[string]string](t, "env=prod,team=core", map[string]string{"env": "prod", "team": "core"})
+	doGVarFlagTest[map[string]int](t, "cpu=2,memory=512", map[string]int{"cpu": 2, "memory": 512})
+	doGVarFlagTest[map[string]bool](t, "a=true,b=false", map[string]bool{"a": true, "b": false})
 }
 
 func TestApp_FromEnv(t *testing.T) {
@@ -151,6 +186,11 @@ func TestApp_FromEnv(t *testing.T) {
 	doGVarEnvTest[uint32](t, "8123567", 8123567)
 	doGVarEnvTest[uint64](t, "10987654321", 10987654321)
 
+	doGVarEnvTest[float32](t, "0.25", 0.25)
+	doGVarEnvTest[float64](t, "3.14159", 3.14159)
+	doGVarEnvTest[float64](t, "1.5e3", 1500)
+	doGVarEnvTest[float64](t, "2E-4", 0.0002)
+
 	doGVarEnvTest[time.Duration](t, "5s", 5*time.Second)
 	doGVarEnvTest[net.IP](t, "127.0.0.1", net.IPv4(127, 0, 0, 1))
 	doGVarEnvTest[net.IP](t, "ff02::1", net.IPv6linklocalallnodes)
@@ -158,6 +198,33 @@ func TestApp_FromEnv(t *testing.T) {
 	// Slices - not comma seperated
 	doGVarEnvTest[[]string](t, "s1 s2", []string{"s1", "s2"})
 	doGVarEnvTest[[]time.Duration](t, "5s 2h", []time.Duration{5 * time.Second, 2 * time.Hour})
+	doGVarEnvTest[[]float32](t, "0.5 1.25", []float32{0.5, 1.25})
+	doGVarEnvTest[[]float64](t, "0.5 1e-10 -2", []float64{0.5, 1e-10, -2})
+	doGVarEnvTest[[]int](t, "80 -443", []int{80, -443})
+	doGVarEnvTest[[]int64](t, "1234567890123 -1", []int64{1234567890123, -1})
+	doGVarEnvTest[[]uint](t, "53 853", []uint{53, 853})
+	doGVarEnvTest[[]bool](t, "true false 1", []bool{true, false, true})
+	doGVarEnvTest[[]net.IP](t, "1.1.1.1 ff02::1", []net.IP{net.IPv4(1, 1, 1, 1), net.IPv6linklocalallnodes})
+	// Slices - comma seperated
+	doGVarEnvTest[[]int](t, "80,443", []int{80, 443})
+	doGVarEnvTest[[]net.IP](t, "1.1.1.1, 8.8.8.8", []net.IP{net.IPv4(1, 1, 1, 1), net.IPv4(8, 8, 8, 8)})
+	doGVarEnvTest[netip.Addr](t, "192.0.2.1", netip.MustParseAddr("192.0.2.1"))
+	doGVarEnvTest[netip.Prefix](t, "fd00::/8", netip.MustParsePrefix("fd00::/8"))
+	doGVarEnvTest[netip.AddrPort](t, "192.0.2.1:80", netip.MustParseAddrPort("192.0.2.1:80"))
+	doGVarEnvTest[net.IPNet](t, "192.0.2.0/24", mustCIDR("192.0.2.0/24"))
+	doGVarEnvTest[HostPort](t, "db.internal:5432", HostPort{"db.internal", 5432})
+	doGVarEnvTest[[]netip.Prefix](t, "10.0.0.0/8 192.168.0.0/16", []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")})
+	doGVarEnvTest[[]HostPort](t, "a:1 b:2", []HostPort{{"a", 1}, {"b", 2}})
+	doGVarEnvTest[*url.URL](t, "postgres://db:5432/app", mustURL("postgres://db:5432/app"))
+	doGVarEnvTest[*regexp.Regexp](t, `\d+`, regexp.MustCompile(`\d+`))
+	doGVarEnvTest[time.Time](t, "2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	doGVarEnvTest[*time.Location](t, "UTC", time.UTC)
+
+	// Maps
+	doGVarEnvTest[map[string]string](t, "env=prod,team=core", map[string]string{"env": "prod", "team": "core"})
+	doGVarEnvTest[map[string]string](t, "url=http://a/?b=c", map[string]string{"url": "http://a/?b=c"})
+	doGVarEnvTest[map[string]int](t, "cpu=2, memory=512", map[string]int{"cpu": 2, "memory": 512})
+	doGVarEnvTest[map[string]bool](t, "a=true,b=0", map[string]bool{"a": true, "b": false})
 }
 
 func TestApp_FromConfig_JSON(t *testing.T) {
@@ -177,6 +244,11 @@ func TestApp_FromConfig_JSON(t *testing.T) {
 	doGVarConfigTest[uint32](t, 8123567, 8123567)
 	doGVarConfigTest[uint64](t, 10987654321, 10987654321)
 
+	doGVarConfigTest[float32](t, 0.25, 0.25)
+	doGVarConfigTest[float64](t, 3.14159, 3.14159)
+	doGVarConfigTest[float64](t, 1e-10, 1e-10)
+	doGVarConfigTest[float64](t, 42, 42)
+
 	doGVarConfigTest[time.Duration](t, "5s", 5*time.Second)
 	doGVarConfigTest[net.IP](t, "127.0.0.1", net.IPv4(127, 0, 0, 1))
 	doGVarEnvTest[net.IP](t, "ff02::1", net.IPv6linklocalallnodes)
@@ -184,6 +256,30 @@ func TestApp_FromConfig_JSON(t *testing.T) {
 	// Slices
 	doGVarConfigTest[[]string](t, []string{"s1", "s2"}, []string{"s1", "s2"})
 	doGVarConfigTest[[]time.Duration](t, []string{"5s", "2h"}, []time.Duration{5 * time.Second, 2 * time.Hour})
+	doGVarConfigTest[[]float32](t, []float32{0.5, 1.25}, []float32{0.5, 1.25})
+	doGVarConfigTest[[]float64](t, []any{0.5, 1e-10, -2}, []float64{0.5, 1e-10, -2})
+	doGVarConfigTest[[]int](t, []int{80, -443}, []int{80, -443})
+	doGVarConfigTest[[]int64](t, []int64{1234567890123, -1}, []int64{1234567890123, -1})
+	doGVarConfigTest[[]uint](t, []uint{53, 1000000}, []uint{53, 1000000})
+	doGVarConfigTest[[]bool](t, []bool{true, false}, []bool{true, false})
+	doGVarConfigTest[[]net.IP](t, []string{"1.1.1.1", "ff02::1"}, []net.IP{net.IPv4(1, 1, 1, 1), net.IPv6linklocalallnodes})
+	doGVarConfigTest[netip.Addr](t, "::1", netip.MustParseAddr("::1"))
+	doGVarConfigTest[netip.Prefix](t, "10.0.0.0/8", netip.MustParsePrefix("10.0.0.0/8"))
+	doGVarConfigTest[netip.AddrPort](t, "192.0.2.1:80", netip.MustParseAddrPort("192.0.2.1:80"))
+	doGVarConfigTest[net.IPNet](t, "10.0.0.0/8", mustCIDR("10.0.0.0/8"))
+	doGVarConfigTest[HostPort](t, "example.com:443", HostPort{"example.com", 443})
+	doGVarConfigTest[[]netip.Addr](t, []string{"1.1.1.1", "::1"}, []netip.Addr{netip.MustParseAddr("1.1.1.1"), netip.MustParseAddr("::1")})
+	doGVarConfigTest[[]net.IPNet](t, []string{"10.0.0.0/8"}, []net.IPNet{mustCIDR("10.0.0.0/8")})
+	doGVarConfigTest[[]HostPort](t, []string{"a:1", "b:2"}, []HostPort{{"a", 1}, {"b", 2}})
+	doGVarConfigTest[*url.URL](t, "https://example.com", mustURL("https://example.com"))
+	doGVarConfigTest[*regexp.Regexp](t, "^[a-z]+$", regexp.MustCompile("^[a-z]+$"))
+	doGVarConfigTest[time.Time](t, "2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	doGVarConfigTest[*time.Location](t, "UTC", time.UTC)
+
+	// Maps
+	doGVarConfigTest[map[string]string](t, map[string]string{"env": "prod", "team": "core"}, map[string]string{"env": "prod", "team": "core"})
+	doGVarConfigTest[map[string]int](t, map[string]int{"cpu": 2, "memory": 1000000}, map[string]int{"cpu": 2, "memory": 1000000})
+	doGVarConfigTest[map[string]bool](t, map[string]bool{"a": true, "b": false}, map[string]bool{"a": true, "b": false})
 }
 
 func TestApp_FromEnvAndFlag(t *testing.T) {
@@ -204,6 +300,9 @@ func TestApp_FromEnvAndFlag(t *testing.T) {
 	doGVarEnvAndFlagTest[uint32](t, "8123567", "7652812", 8123567)
 	doGVarEnvAndFlagTest[uint64](t, "10987654321", "12345678901", 10987654321)
 
+	doGVarEnvAndFlagTest[float32](t, "0.25", "0.5", 0.25)
+	doGVarEnvAndFlagTest[float64](t, "3.14159", "2.71828", 3.14159)
+
 	doGVarEnvAndFlagTest[time.Duration](t, "5s", "10h", 5*time.Second)
 	doGVarEnvAndFlagTest[net.IP](t, "127.0.0.1", "1.2.3.4", net.IPv4(127, 0, 0, 1))
 	doGVarEnvAndFlagTest[net.IP](t, "ff02::1", "ab:cd:ef:12:34:56:78::90", net.IPv6linklocalallnodes)
@@ -211,6 +310,23 @@ func TestApp_FromEnvAndFlag(t *testing.T) {
 	// Slices
 	doGVarEnvAndFlagTest[[]string](t, "s1,s2", "first second", []string{"s1", "s2"})
 	doGVarEnvAndFlagTest[[]time.Duration](t, "5s,2h", "6m 3ms", []time.Duration{5 * time.Second, 2 * time.Hour})
+	doGVarEnvAndFlagTest[[]float32](t, "0.5,1.25", "2 3", []float32{0.5, 1.25})
+	doGVarEnvAndFlagTest[[]float64](t, "0.5,1e-10", "2 3", []float64{0.5, 1e-10})
+	doGVarEnvAndFlagTest[[]int](t, "80,443", "8080 8443", []int{80, 443})
+	doGVarEnvAndFlagTest[[]int64](t, "1,2", "3 4", []int64{1, 2})
+	doGVarEnvAndFlagTest[[]uint](t, "53", "54 55", []uint{53})
+	doGVarEnvAndFlagTest[[]bool](t, "false", "true true", []bool{false})
+	doGVarEnvAndFlagTest[[]net.IP](t, "1.1.1.1", "8.8.8.8", []net.IP{net.IPv4(1, 1, 1, 1)})
+	doGVarEnvAndFlagTest[netip.Addr](t, "::1", "192.0.2.1", netip.MustParseAddr("::1"))
+	doGVarEnvAndFlagTest[net.IPNet](t, "10.0.0.0/8", "192.0.2.0/24", mustCIDR("10.0.0.0/8"))
+	doGVarEnvAndFlagTest[HostPort](t, "a:1", "b:2", HostPort{"a", 1})
+	doGVarEnvAndFlagTest[*url.URL](t, "https://a.example.com", "https://b.example.com", mustURL("https://a.example.com"))
+	doGVarEnvAndFlagTest[time.Time](t, "2024-03-01T12:00:00Z", "2025-01-01T00:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	// Maps are replaced rather than merged
+	doGVarEnvAndFlagTest[map[string]string](t, "env=prod", "team=core", map[string]string{"env": "prod"})
+	doGVarEnvAndFlagTest[map[string]int](t, "cpu=2", "memory=512", map[string]int{"cpu": 2})
+	doGVarEnvAndFlagTest[map[string]bool](t, "a=true", "b=true", map[string]bool{"a": true})
 }
 
 // TODO fuzz tests
@@ -233,10 +349,141 @@ func TestApp_ManualFromConfig(t *testing.T) {
 }
 
 func TestApp_VarsThatPanic(t *testing.T) {
-	// Type aliases
-	// TODO - it is likely possible to hanlde custom types, just needs investigation
-	type StringAlias string
-	assertPanics[StringAlias](t, gVarTest("testStringAlias", "", "", StringAlias("testStringAlias")))
+	// Structs that don't parse themselves
+	type Unsupported struct{ Name string }
+	assertPanics[Unsupported](t, gVarTest("", "", nil, Unsupported{}))
+	assertPanics[chan int](t, gVarTest[chan int]("", "", nil, nil))
+}
+
+func TestApp_SliceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"flag int", []string{"--ints=80,http"}, "", `unable to parse item 2 "http"`},
+		{"flag ip", []string{"--ips=1.1.1.1,example.com"}, "", `invalid IP address "example.com"`},
+		{"env int", nil, "80 443 http", `unable to parse item 3 "http"`},
+		{"env int commas", nil, "80,,443", `unable to parse item 2 ""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_SLICE", test.env)
+			app := subject()
+			var ints []int
+			var ips []net.IP
+			app.genericVar(&ints, VarName("ints"), VarEnv("TEST_SLICE"))
+			app.genericVar(&ips, VarName("ips"))
+
+			err := app.Cmd.ParseFlags(test.args)
+			// Environment variables are parsed when the App loads
+			if err == nil {
+				err = app.InitNoConfig()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestApp_FloatErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      string
+		config   string
+		expected string
+	}{
+		{"env", "abc", "", `invalid value for ratio: strconv.ParseFloat: parsing "abc": invalid syntax`},
+		{"config", "", "ratio: abc\n", `invalid value for ratio: strconv.ParseFloat: parsing "abc": invalid syntax`},
+		{"config float32", "", "weight: heavy\n", `invalid value for weight: strconv.ParseFloat: parsing "heavy": invalid syntax`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_RATIO", test.env)
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), test.config)
+			app := New(&cobra.Command{Use: "tool"})
+			var ratio float64
+			var weight float32
+			app.genericVar(&ratio, VarName("ratio"), VarEnv("TEST_RATIO"))
+			app.genericVar(&weight, VarName("weight"))
+			app.Init(path, "")
+
+			err := app.load()
+			if err == nil || err.Error() != test.expected {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestApp_LoadInvalidConfigValue(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "ports: [1, x]\n")
+	app := New(&cobra.Command{Use: "tool"})
+	var ports []int
+	app.Var(&ports, "ports", []int(nil), "")
+	app.Init(path, "")
+
+	err := app.load()
+	expected := `invalid value for ports: unable to parse item 2 "x"`
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected '%s' got '%v'", expected, err)
+	}
+}
+
+func TestApp_MapFlagRepeated(t *testing.T) {
+	app := subject()
+	labels := map[string]string{}
+	limits := map[string]bool{}
+	app.genericVar(&labels, VarName("label"), VarDefaultValue(map[string]string{"default": "true"}))
+	app.genericVar(&limits, VarName("limit"))
+	err := app.Cmd.ParseFlags([]string{"--label", "env=prod", "--label", "team=core", "--limit", "a=true", "--limit", "b=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+
+	expected := map[string]string{"env": "prod", "team": "core"}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected '%v' got '%v'", expected, labels)
+	}
+	if !reflect.DeepEqual(limits, map[string]bool{"a": true, "b": false}) {
+		t.Errorf("expected '%v' got '%v'", map[string]bool{"a": true, "b": false}, limits)
+	}
+}
+
+func TestApp_MapErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"flag", []string{"--limits=cpu=two"}, "", `invalid argument "cpu=two" for "--limits" flag`},
+		{"env", nil, "cpu=2,memory=lots", `unable to parse key "memory"`},
+		{"env not a pair", nil, "cpu", `unable to parse "cpu", expected key=value`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_MAP", test.env)
+			app := subject()
+			var limits map[string]int
+			app.genericVar(&limits, VarName("limits"), VarEnv("TEST_MAP"))
+
+			err := app.Cmd.ParseFlags(test.args)
+			// Environment variables are parsed when the App loads
+			if err == nil {
+				err = app.InitNoConfig()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
 }
 
 func assertPanics[T any](t *testing.T, fn func(t *testing.T)) {
@@ -250,3 +497,71 @@ func assertPanics[T any](t *testing.T, fn func(t *testing.T)) {
 		fn(t)
 	})
 }
+
+func TestApp_VarLocal(t *testing.T) {
+	app := subject()
+	var local string
+	app.genericVar(&local, VarName("local"), VarLocal())
+	err := app.Cmd.ParseFlags([]string{"--local=value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+
+	if app.Cmd.PersistentFlags().Lookup("local") != nil {
+		t.Error("local flag should not persist to sub-commands")
+	}
+	if local != "value" {
+		t.Errorf("expected 'value' got '%s'", local)
+	}
+}
+
+func TestApp_EnvPrefix(t *testing.T) {
+	t.Setenv("MYTOOL_LOG_LEVEL", "debug")
+	t.Setenv("MYTOOL_SERVER_PORT", "8080")
+	t.Setenv("MYTOOL_FOO_NAME", "child")
+	t.Setenv("MYTOOL_EXPLICIT", "generated")
+	t.Setenv("EXPLICIT", "explicit")
+
+	app := New(&cobra.Command{Use: "tool"}, AppEnvPrefix("MYTOOL"))
+	var logLevel, name, explicit string
+	var port int
+	app.StringVar(&logLevel, "log-level", "", "usage")
+	app.IntVar(&port, "server.port", 0, "usage")
+	app.genericVar(&explicit, VarName("explicit"), VarEnv("EXPLICIT"))
+	child := app.Child(New(&cobra.Command{Use: "foo"}))
+	child.StringVar(&name, "name", "", "usage")
+	app.InitNoConfig()
+
+	if logLevel != "debug" {
+		t.Errorf("expected 'debug' got '%s'", logLevel)
+	}
+	if port != 8080 {
+		t.Errorf("expected '8080' got '%d'", port)
+	}
+	if name != "child" {
+		t.Errorf("expected child to inherit the prefix got '%s'", name)
+	}
+	if explicit != "explicit" {
+		t.Errorf("expected explicit env to take precedence got '%s'", explicit)
+	}
+	if env := app.configEnv(); env != "MYTOOL_CONFIG" {
+		t.Errorf("expected 'MYTOOL_CONFIG' got '%s'", env)
+	}
+}
+
+func mustCIDR(s string) net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return *ipNet
+}
+
+func mustURL(s string) *url.URL {
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
@@ -0,0 +1,65 @@
+package ezcli
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// HostPort is a host name or IP address and a port, eg: example.com:443 or [2001:db8::1]:443
+// The port can be left out when the variable has a default port, set with VarDefaultPort or a port:"" struct tag
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// String joins the host and port, the zero value is empty
+func (h HostPort) String() string {
+	if h == (HostPort{}) {
+		return ""
+	}
+	return net.JoinHostPort(h.Host, strconv.Itoa(int(h.Port)))
+}
+
+// MarshalText writes the HostPort to config files as text
+func (h HostPort) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// parseHostPort parses host:port, using the default port when there is one and s has no port
+func parseHostPort(defaultPort uint16) func(string) (HostPort, error) {
+	expected := "host:port such as example.com:443"
+	if defaultPort != 0 {
+		expected = "host or host:port such as example.com:443"
+	}
+	return func(s string) (HostPort, error) {
+		host, port, err := net.SplitHostPort(s)
+		if err != nil {
+			if defaultPort == 0 {
+				return HostPort{}, errors.Errorf("invalid address %q, expected %s", s, expected)
+			}
+			// A host without a port, IPv6 addresses may or may not be bracketed
+			host, port = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"), strconv.Itoa(int(defaultPort))
+			if strings.Contains(host, ":") {
+				if _, err := netip.ParseAddr(host); err != nil {
+					return HostPort{}, errors.Errorf("invalid address %q, expected %s", s, expected)
+				}
+			}
+		}
+		if host == "" || strings.ContainsAny(host, " \t[]") {
+			return HostPort{}, errors.Errorf("invalid host in %q, expected %s", s, expected)
+		}
+		p, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return HostPort{}, errors.Errorf("invalid port %q in %q, expected a number from 0 to 65535", port, s)
+		}
+		return HostPort{Host: host, Port: uint16(p)}, nil
+	}
+}
+
+func formatHostPort(h HostPort) string {
+	return h.String()
+}
@@ -0,0 +1,188 @@
+package ezcli
+
+import (
+	"net"
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseHostPort(t *testing.T) {
+	tests := []struct {
+		in          string
+		defaultPort uint16
+		expected    HostPort
+		err         string
+	}{
+		{"example.com:443", 0, HostPort{"example.com", 443}, ""},
+		{"example.com:443", 80, HostPort{"example.com", 443}, ""},
+		{"example.com", 80, HostPort{"example.com", 80}, ""},
+		{"[2001:db8::1]:443", 0, HostPort{"2001:db8::1", 443}, ""},
+		{"[2001:db8::1]", 80, HostPort{"2001:db8::1", 80}, ""},
+		{"2001:db8::1", 80, HostPort{"2001:db8::1", 80}, ""},
+		{"example.com", 0, HostPort{}, `invalid address "example.com", expected host:port such as example.com:443`},
+		{"a:b:c", 80, HostPort{}, `invalid address "a:b:c", expected host or host:port`},
+		{":443", 0, HostPort{}, `invalid host in ":443"`},
+		{"example.com:https", 0, HostPort{}, `invalid port "https" in "example.com:https", expected a number from 0 to 65535`},
+		{"example.com:70000", 0, HostPort{}, `invalid port "70000"`},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			hostPort, err := parseHostPort(test.defaultPort)(test.in)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected '%s' got '%v'", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hostPort != test.expected {
+				t.Errorf("expected '%s' got '%s'", test.expected, hostPort)
+			}
+		})
+	}
+}
+
+func TestApp_NetworkErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"flag addr", []string{"--addr=example.com"}, "", `invalid IP address "example.com", expected IPv4 or IPv6`},
+		{"flag prefix", []string{"--prefix=10.0.0.0"}, "", `invalid CIDR "10.0.0.0", expected an IP address and prefix length`},
+		{"flag addr port", []string{"--addr-port=10.0.0.1"}, "", `invalid address "10.0.0.1", expected an IP address and port`},
+		{"flag host port", []string{"--host-port=a:b:c"}, "", `invalid address "a:b:c", expected host or host:port`},
+		{"env", nil, "10.0.0.0/8 10.0.0.1", `invalid value for prefixes: unable to parse item 2 "10.0.0.1": invalid CIDR`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_PREFIXES", test.env)
+			app := subject()
+			var addr netip.Addr
+			var prefix netip.Prefix
+			var addrPort netip.AddrPort
+			var hostPort HostPort
+			var prefixes []netip.Prefix
+			app.genericVar(&addr, VarName("addr"))
+			app.genericVar(&prefix, VarName("prefix"))
+			app.genericVar(&addrPort, VarName("addr-port"))
+			app.genericVar(&hostPort, VarName("host-port"), VarDefaultPort(80))
+			app.genericVar(&prefixes, VarName("prefixes"), VarEnv("TEST_PREFIXES"))
+
+			err := app.Cmd.ParseFlags(test.args)
+			if err == nil {
+				err = app.InitNoConfig()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestApp_HostPortDefaultPort(t *testing.T) {
+	app := subject()
+	var hostPort HostPort
+	var hostPorts []HostPort
+	app.genericVar(&hostPort, VarName("server"), VarDefaultValue(HostPort{"localhost", 8080}), VarDefaultPort(8080))
+	app.genericVar(&hostPorts, VarName("peers"), VarDefaultPort(7946))
+	if flag := app.Cmd.PersistentFlags().Lookup("server"); flag.DefValue != "localhost:8080" || flag.Value.Type() != "hostPort" {
+		t.Errorf("expected 'hostPort' defaulting to 'localhost:8080' got '%s' '%s'", flag.Value.Type(), flag.DefValue)
+	}
+
+	err := app.Cmd.ParseFlags([]string{"--server=example.com", "--peers=a,b:1,[::1]"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+	if hostPort != (HostPort{"example.com", 8080}) {
+		t.Errorf("expected 'example.com:8080' got '%s'", hostPort)
+	}
+	expected := []HostPort{{"a", 7946}, {"b", 1}, {"::1", 7946}}
+	if len(hostPorts) != len(expected) {
+		t.Fatalf("expected '%v' got '%v'", expected, hostPorts)
+	}
+	for i := range expected {
+		if hostPorts[i] != expected[i] {
+			t.Errorf("expected '%s' got '%s'", expected[i], hostPorts[i])
+		}
+	}
+}
+
+func TestApp_StructVarNetworkTypes(t *testing.T) {
+	s := &struct {
+		IP       net.IP
+		Listen   HostPort `port:"8080"`
+		Networks []netip.Prefix
+		Subnet   net.IPNet `env:"TEST_SUBNET"`
+	}{}
+	t.Setenv("TEST_SUBNET", "10.1.0.0/16")
+
+	app := New(&cobra.Command{Use: "tool"})
+	app.StructVar(s)
+	err := app.Cmd.ParseFlags([]string{"--IP=192.0.2.1", "--Listen=0.0.0.0", "--Networks=10.0.0.0/8,fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+
+	if s.IP.String() != "192.0.2.1" {
+		t.Errorf("expected '192.0.2.1' got '%s'", s.IP)
+	}
+	if s.Listen != (HostPort{"0.0.0.0", 8080}) {
+		t.Errorf("expected '0.0.0.0:8080' got '%s'", s.Listen)
+	}
+	if len(s.Networks) != 2 || s.Networks[1] != netip.MustParsePrefix("fd00::/8") {
+		t.Errorf("expected '[10.0.0.0/8 fd00::/8]' got '%v'", s.Networks)
+	}
+	if s.Subnet.String() != "10.1.0.0/16" {
+		t.Errorf("expected '10.1.0.0/16' got '%s'", s.Subnet.String())
+	}
+}
+
+func TestApp_ConfigInitNetworkTypes(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool."+format)
+			listen := HostPort{"localhost", 8080}
+			subnets := []net.IPNet{mustCIDR("10.0.0.0/8")}
+			addr := netip.MustParseAddr("::1")
+			app := New(&cobra.Command{Use: "tool"})
+			var s struct {
+				Listen  HostPort
+				Subnets []net.IPNet
+				Addr    netip.Addr
+			}
+			app.Var(&s.Listen, "listen", listen, "usage")
+			app.Var(&s.Subnets, "subnets", subnets, "usage")
+			app.Var(&s.Addr, "addr", addr, "usage")
+			app.AddConfigCommands()
+			_, err := runCmd(t, app, "config", "init", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			app = New(&cobra.Command{Use: "tool"})
+			app.Var(&s.Listen, "listen", HostPort{}, "usage")
+			app.Var(&s.Subnets, "subnets", []net.IPNet(nil), "usage")
+			app.Var(&s.Addr, "addr", netip.Addr{}, "usage")
+			app.Init(path, "")
+			err = app.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Listen != listen || !reflect.DeepEqual(s.Subnets, subnets) || s.Addr != addr {
+				t.Errorf("expected '%s' '%v' '%s' got '%s' '%v' '%s'", listen, subnets, addr, s.Listen, s.Subnets, s.Addr)
+			}
+		})
+	}
+}
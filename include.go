@@ -0,0 +1,139 @@
+package ezcli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	includeKey = "include"
+	confDir    = "conf.d"
+)
+
+// readConfigFiles merges a config file into the settings followed by every file it includes
+// stack holds the files including this one so include cycles can be found
+// Included files and conf.d fragments pass the version of the file they belong to,
+// top level files pass nil and are migrated from their own version, which is returned
+func (a *App) readConfigFiles(load *configLoad, path string, stack []string, belongsTo *int) (int, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to find config file %s", path)
+	}
+	for i, including := range stack {
+		if including == abs {
+			return 0, errors.Errorf("include cycle %s", strings.Join(append(stack[i:], abs), " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	fileSettings, err := readConfigFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	if belongsTo == nil {
+		version, err = a.migrateConfig(fileSettings)
+	} else {
+		version = *belongsTo
+		err = a.migrateFragment(fileSettings, version)
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to migrate config file %s", path)
+	}
+	includes, err := includedFiles(fileSettings, path)
+	if err != nil {
+		return 0, err
+	}
+	delete(fileSettings, includeKey)
+
+	a.mergeConfig(load, fileSettings, Source{Kind: SourceFile, Detail: path})
+	load.files = append(load.files, path)
+	// TODO use log
+	fmt.Fprintln(a.Cmd.ErrOrStderr(), "Using config file:", path)
+
+	// Included files take priority over the file including them
+	for _, include := range includes {
+		_, err = a.readConfigFiles(load, include, stack, &version)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
+// includedFiles are the files matching the include globs of a config file in lexical order
+// Relative globs are relative to the directory of the config file
+func includedFiles(settings map[string]any, path string) ([]string, error) {
+	var patterns []string
+	switch include := settings[includeKey].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = []string{include}
+	case []any:
+		for _, pattern := range include {
+			s, ok := pattern.(string)
+			if !ok {
+				return nil, errors.Errorf("include in %s must be a list of paths, got %v", path, pattern)
+			}
+			patterns = append(patterns, s)
+		}
+	default:
+		return nil, errors.Errorf("include in %s must be a list of paths, got %v", path, include)
+	}
+
+	files := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		expanded, err := homedir.Expand(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to expand include %s in %s", pattern, path)
+		}
+		if !filepath.IsAbs(expanded) {
+			expanded = filepath.Join(filepath.Dir(path), expanded)
+		}
+		matches, err := filepath.Glob(expanded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid include %s in %s", pattern, path)
+		}
+		// Globs may match nothing but a plain path must exist
+		if len(matches) == 0 && !hasGlob(pattern) {
+			return nil, errors.Errorf("included file %s in %s does not exist", pattern, path)
+		}
+		for _, match := range matches {
+			if fileExists(match) {
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// confDirOf is the conf.d directory next to a config file
+func confDirOf(path string) string {
+	return filepath.Join(filepath.Dir(path), confDir)
+}
+
+// confFragments are the config files in a conf.d directory in lexical order
+func confFragments(dir string) []string {
+	fragments := make([]string, 0)
+	for _, ext := range viper.SupportedExts {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*."+ext))
+		for _, match := range matches {
+			if fileExists(match) {
+				fragments = append(fragments, match)
+			}
+		}
+	}
+	sort.Strings(fragments)
+	return fragments
+}
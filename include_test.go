@@ -0,0 +1,145 @@
+package ezcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func includeApp(host, port, region *string) *App {
+	app := New(&cobra.Command{Use: "tool"}, AppStrictConfig())
+	app.StringVar(host, "host", "", "usage")
+	app.StringVar(port, "port", "", "usage")
+	app.StringVar(region, "region", "", "usage")
+	return app
+}
+
+func TestApp_Include(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, filepath.Join(dir, "tool.yaml"), `
+host: main
+port: main
+region: main
+include:
+  - hosts/*.yaml
+  - region.json
+`)
+	writeFile(t, filepath.Join(dir, "hosts", "b.yaml"), "host: b\n")
+	writeFile(t, filepath.Join(dir, "hosts", "a.yaml"), "host: a\nport: a\n")
+	writeFile(t, filepath.Join(dir, "region.json"), `{"region":"json","include":"nested/*.toml"}`)
+	writeFile(t, filepath.Join(dir, "nested", "region.toml"), `region = "nested"`)
+
+	var host, port, region string
+	app := includeApp(&host, &port, &region)
+	app.Init(path, "")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct{ expected, got string }{
+		{"b", host},
+		{"a", port},
+		{"nested", region},
+	} {
+		if test.expected != test.got {
+			t.Errorf("expected '%s' got '%s'", test.expected, test.got)
+		}
+	}
+	if source := app.Source("host"); source.Detail != filepath.Join(dir, "hosts", "b.yaml") {
+		t.Errorf("expected host from b.yaml got '%s'", source)
+	}
+}
+
+func TestApp_ConfDir(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, filepath.Join(dir, "tool.yaml"), "host: main\nport: main\nregion: main\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "20-host.yaml"), "host: twenty\n")
+	writeFile(t, filepath.Join(dir, "conf.d", "10-host.json"), `{"host":"ten","port":"ten"}`)
+	writeFile(t, filepath.Join(dir, "conf.d", "notes.txt"), "not config")
+	err := os.Mkdir(filepath.Join(dir, "conf.d", "30-dir.yaml"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var host, port, region string
+	app := includeApp(&host, &port, &region)
+	app.Init(path, "")
+	err = app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct{ expected, got string }{
+		{"twenty", host},
+		{"ten", port},
+		{"main", region},
+	} {
+		if test.expected != test.got {
+			t.Errorf("expected '%s' got '%s'", test.expected, test.got)
+		}
+	}
+}
+
+func TestApp_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+		fragment string
+	}{
+		{
+			"cycle",
+			map[string]string{"tool.yaml": "include: a.yaml\n", "a.yaml": "include: b.yaml\n", "b.yaml": "include: a.yaml\n"},
+			"include cycle", "",
+		},
+		{
+			"self",
+			map[string]string{"tool.yaml": "include: tool.yaml\n"},
+			"include cycle", "",
+		},
+		{
+			"missing",
+			map[string]string{"tool.yaml": "include: missing.yaml\n"},
+			"included file missing.yaml", "tool.yaml",
+		},
+		{
+			"not a path",
+			map[string]string{"tool.yaml": "include: [1]\n"},
+			"must be a list of paths", "tool.yaml",
+		},
+		{
+			"malformed fragment",
+			map[string]string{"tool.yaml": "include: a.json\n", "a.json": `{"host":`},
+			"unable to read config file", "a.json",
+		},
+		{
+			"unknown key in fragment",
+			map[string]string{"tool.yaml": "host: main\n", "conf.d/a.yaml": "hots: a\n"},
+			`unknown config key "hots"`, "a.yaml",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.files {
+				writeFile(t, filepath.Join(dir, name), content)
+			}
+			var host, port, region string
+			app := includeApp(&host, &port, &region)
+			app.Init(filepath.Join(dir, "tool.yaml"), "")
+
+			err := app.load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), test.fragment) {
+				t.Errorf("expected '%s' in %s got '%v'", test.expected, test.fragment, err)
+			}
+		})
+	}
+}
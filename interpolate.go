@@ -0,0 +1,161 @@
+package ezcli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// interpolator replaces ${...} references in config values
+//
+//	${name}          - the config key name, or the environment variable name if there is no such key
+//	${name:-default} - as above, using default when the value is unset or empty
+//	$${              - a literal ${
+type interpolator struct {
+	settings  map[string]any
+	resolved  map[string]any
+	resolving []string // Keys being resolved, used to find cycles
+	lookupEnv func(name string) (string, bool)
+}
+
+// interpolateConfig resolves every reference in the settings' string values
+// Profiles that aren't active are left alone, the active one is already merged in
+func (a *App) interpolateConfig(load *configLoad) error {
+	settings := load.settings
+	i := &interpolator{
+		settings:  settings,
+		resolved:  make(map[string]any),
+		lookupEnv: a.lookupEnv,
+	}
+	// Sorted so errors are the same every run
+	keys := flattenKeys(settings, "")
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, inProfile := profileKey(key); inProfile && a.opts.profiles {
+			continue
+		}
+		_, err := i.resolve(key)
+		if err != nil {
+			return errors.Wrapf(err, "unable to interpolate %s in %s", key, load.sources[key].Detail)
+		}
+	}
+	i.replace(settings, "")
+	return nil
+}
+
+// lookup finds the value of a flattened key
+func (i *interpolator) lookup(key string) (any, bool) {
+	return lookupValue(i.settings, key)
+}
+
+// resolve interpolates the value of a key, following references to other keys
+func (i *interpolator) resolve(key string) (any, error) {
+	if value, ok := i.resolved[key]; ok {
+		return value, nil
+	}
+	for n, resolving := range i.resolving {
+		if resolving == key {
+			return nil, errors.Errorf("reference cycle %s", strings.Join(append(i.resolving[n:], key), " -> "))
+		}
+	}
+	i.resolving = append(i.resolving, key)
+	defer func() { i.resolving = i.resolving[:len(i.resolving)-1] }()
+
+	value, _ := i.lookup(key)
+	value, err := i.expandValue(value)
+	if err != nil {
+		return nil, err
+	}
+	i.resolved[key] = value
+	return value, nil
+}
+
+func (i *interpolator) expandValue(value any) (any, error) {
+	switch val := value.(type) {
+	case string:
+		return i.expand(val)
+	case []any:
+		expanded := make([]any, len(val))
+		for n, item := range val {
+			var err error
+			expanded[n], err = i.expandValue(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	}
+	return value, nil
+}
+
+// expand replaces every reference in s
+func (i *interpolator) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	out := &strings.Builder{}
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, "$${"):
+			out.WriteString("${")
+			s = s[3:]
+		case strings.HasPrefix(s, "${"):
+			end := strings.Index(s, "}")
+			if end < 0 {
+				return "", errors.Errorf("missing } after %s", s)
+			}
+			value, err := i.reference(s[2:end])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(value)
+			s = s[end+1:]
+		default:
+			out.WriteByte(s[0])
+			s = s[1:]
+		}
+	}
+	return out.String(), nil
+}
+
+// reference is the value of a single ${...} reference
+func (i *interpolator) reference(expr string) (string, error) {
+	name, fallback, hasFallback := strings.Cut(expr, ":-")
+	if name == "" {
+		return "", errors.New("empty reference ${}")
+	}
+
+	value := ""
+	key := strings.ToLower(name)
+	if _, ok := i.lookup(key); ok {
+		resolved, err := i.resolve(key)
+		if err != nil {
+			return "", err
+		}
+		value = fmt.Sprint(resolved)
+	} else if env, ok := i.lookupEnv(name); ok {
+		value = env
+	} else if !hasFallback {
+		return "", errors.Errorf("${%s} is not a config key or environment variable", name)
+	}
+
+	if value == "" && hasFallback {
+		return fallback, nil
+	}
+	return value, nil
+}
+
+// replace writes every resolved value back into the settings
+func (i *interpolator) replace(m map[string]any, prefix string) {
+	for key, value := range m {
+		if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+			i.replace(nested, prefix+key+".")
+			continue
+		}
+		if resolved, ok := i.resolved[prefix+key]; ok {
+			m[key] = resolved
+		}
+	}
+}
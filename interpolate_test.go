@@ -0,0 +1,134 @@
+package ezcli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApp_Interpolate(t *testing.T) {
+	t.Setenv("TOOL_TEST_HOST", "env.example.com")
+	t.Setenv("TOOL_TEST_EMPTY", "")
+
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"env", "url: http://${TOOL_TEST_HOST}/\n", "http://env.example.com/"},
+		{"key", "host: key.example.com\nurl: http://${host}/\n", "http://key.example.com/"},
+		{"nested key", "server:\n  host: nested.example.com\nurl: ${server.host}\n", "nested.example.com"},
+		{"chained keys", "a: ${b}\nb: ${c}\nc: end\nurl: ${a}\n", "end"},
+		{"number", "port: 8080\nurl: localhost:${port}\n", "localhost:8080"},
+		{"default unset", "url: ${TOOL_TEST_MISSING:-fallback}\n", "fallback"},
+		{"default empty", "url: ${TOOL_TEST_EMPTY:-fallback}\n", "fallback"},
+		{"default set", "url: ${TOOL_TEST_HOST:-fallback}\n", "env.example.com"},
+		{"escaped", "url: $${TOOL_TEST_HOST}\n", "${TOOL_TEST_HOST}"},
+		{"plain dollar", "url: $HOME $5\n", "$HOME $5"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), test.config)
+			app := New(&cobra.Command{Use: "tool"})
+			var url string
+			app.StringVar(&url, "url", "", "usage")
+			app.Init(path, "")
+
+			err := app.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if url != test.expected {
+				t.Errorf("expected '%s' got '%s'", test.expected, url)
+			}
+		})
+	}
+}
+
+func TestApp_InterpolateSlice(t *testing.T) {
+	t.Setenv("TOOL_TEST_HOST", "env.example.com")
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "hosts: [\"${TOOL_TEST_HOST}\", other]\n")
+	app := New(&cobra.Command{Use: "tool"})
+	var hosts []string
+	app.Var(&hosts, "hosts", []string(nil), "usage")
+	app.Init(path, "")
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(hosts, ",") != "env.example.com,other" {
+		t.Errorf("expected 'env.example.com,other' got '%v'", hosts)
+	}
+}
+
+func TestApp_InterpolateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"cycle", "a: ${b}\nb: ${c}\nc: ${a}\n", "reference cycle a -> b -> c -> a"},
+		{"self", "a: x${a}\n", "reference cycle a -> a"},
+		{"undefined", "a: ${TOOL_TEST_UNDEFINED}\n", "${TOOL_TEST_UNDEFINED} is not a config key or environment variable"},
+		{"unterminated", "a: ${b\n", "missing }"},
+		{"empty", "a: ${}\n", "empty reference"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), test.config)
+			app := New(&cobra.Command{Use: "tool"})
+			app.Init(path, "")
+
+			err := app.load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), path) {
+				t.Errorf("expected '%s' in %s got '%v'", test.expected, path, err)
+			}
+		})
+	}
+}
+
+func TestApp_InterpolateInactiveProfile(t *testing.T) {
+	config := "url: ${port}\nport: 80\nprofiles:\n  prod:\n    port: ${TOOL_TEST_PROD_ONLY}\n"
+	tests := []struct {
+		name     string
+		profile  string
+		expected string
+		err      string
+	}{
+		{"inactive", "", "80", ""},
+		{"active", "prod", "", "${TOOL_TEST_PROD_ONLY} is not a config key or environment variable"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), config)
+			t.Setenv("TOOL_PROFILE", test.profile)
+			app := New(&cobra.Command{Use: "tool"}, AppProfiles())
+			var url string
+			app.StringVar(&url, "url", "", "usage")
+			app.Init(path, "")
+
+			err := app.load()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected '%s' got '%v'", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if url != test.expected {
+				t.Errorf("expected '%s' got '%s'", test.expected, url)
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package ezcli
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// versionKey holds the version of a config file once migrations are registered
+const versionKey = "version"
+
+// migration upgrades a config file from one version to another
+type migration struct {
+	from, to int
+	fn       func(settings map[string]any) error
+}
+
+// RegisterMigration upgrades config files at version from to version to
+// fn changes the file's settings in place, keys are lower cased and nested maps are sections
+// Files without a version key are version 0, the highest to is the current version
+func (a *App) RegisterMigration(from, to int, fn func(settings map[string]any) error) {
+	if to <= from {
+		panic(fmt.Sprintf("migration from version %d must be to a later version, got %d", from, to))
+	}
+	root := a.root()
+	for _, m := range root.migrations {
+		if m.from == from {
+			panic(fmt.Sprintf("a migration from version %d is already registered", from))
+		}
+	}
+	root.migrations = append(root.migrations, migration{from: from, to: to, fn: fn})
+}
+
+// configVersion is the version of config files the App reads, 0 without migrations
+func (a *App) configVersion() int {
+	latest := 0
+	for _, m := range a.root().migrations {
+		if m.to > latest {
+			latest = m.to
+		}
+	}
+	return latest
+}
+
+// migrateConfig upgrades the settings of a config file to the current version
+// The version key is removed from the settings, the file's version is returned
+func (a *App) migrateConfig(settings map[string]any) (int, error) {
+	root := a.root()
+	if len(root.migrations) == 0 {
+		return 0, nil
+	}
+	version, err := settingsVersion(settings)
+	if err != nil {
+		return 0, err
+	}
+	delete(settings, versionKey)
+	return version, root.migrateFrom(settings, version)
+}
+
+// migrateFragment upgrades the settings of an included file or conf.d fragment
+// Fragments share the version of the file they belong to and can't hold another
+func (a *App) migrateFragment(settings map[string]any, version int) error {
+	root := a.root()
+	if len(root.migrations) == 0 {
+		return nil
+	}
+	if _, ok := settings[versionKey]; ok {
+		fragmentVersion, err := settingsVersion(settings)
+		if err != nil {
+			return err
+		}
+		if fragmentVersion != version {
+			return errors.Errorf("version %d doesn't match version %d of the file it belongs to", fragmentVersion, version)
+		}
+		delete(settings, versionKey)
+	}
+	return root.migrateFrom(settings, version)
+}
+
+// migrateFrom runs every migration from version to the current version
+func (a *App) migrateFrom(settings map[string]any, version int) error {
+	latest := a.configVersion()
+	if version > latest {
+		return errors.Errorf("version %d is newer than the latest version %d", version, latest)
+	}
+	for current := version; current < latest; {
+		m, ok := a.migrationFrom(current)
+		if !ok {
+			return errors.Errorf("no migration from version %d", current)
+		}
+		err := m.fn(settings)
+		if err != nil {
+			return errors.Wrapf(err, "unable to migrate from version %d to %d", m.from, m.to)
+		}
+		current = m.to
+	}
+	return nil
+}
+
+func (a *App) migrationFrom(version int) (migration, bool) {
+	for _, m := range a.migrations {
+		if m.from == version {
+			return m, true
+		}
+	}
+	return migration{}, false
+}
+
+// settingsVersion reads the version key, each config format decodes numbers differently
+func settingsVersion(settings map[string]any) (int, error) {
+	value, ok := settings[versionKey]
+	if !ok {
+		return 0, nil
+	}
+	var version float64
+	switch val := value.(type) {
+	case int:
+		version = float64(val)
+	case int64:
+		version = float64(val)
+	case float64:
+		version = val
+	default:
+		return 0, errors.Errorf("version must be a whole number, got %v", value)
+	}
+	if version < 0 || version != math.Trunc(version) {
+		return 0, errors.Errorf("version must be a whole number, got %v", value)
+	}
+	return int(version), nil
+}
+
+// mapSection holds the settings of a config file, keys are sorted so files are written the same every time
+func mapSection(settings map[string]any) *configSection {
+	s := &configSection{}
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if nested, ok := settings[key].(map[string]any); ok && len(nested) > 0 {
+			section := mapSection(nested)
+			section.name = key
+			s.sections = append(s.sections, section)
+			continue
+		}
+		s.keys = append(s.keys, configKey{name: key, value: settings[key]})
+	}
+	return s
+}
+
+// withVersion adds the version key to the start of a section
+func withVersion(s *configSection, version int) *configSection {
+	s.keys = append([]configKey{{name: versionKey, usage: "config file version", value: version}}, s.keys...)
+	return s
+}
+
+// replaceFile atomically replaces a file's contents after copying it to a .bak file
+// A missing file is created without a backup
+func replaceFile(path string, b []byte) (string, error) {
+	perm, backup := os.FileMode(0o644), ""
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to create directory for %s", path)
+		}
+	case err != nil:
+		return "", errors.Wrapf(err, "unable to read %s", path)
+	default:
+		perm = info.Mode().Perm()
+		original, err := os.ReadFile(path)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to read %s", path)
+		}
+		backup = path + ".bak"
+		err = os.WriteFile(backup, original, perm)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to write backup %s", backup)
+		}
+	}
+
+	// Write beside the file so the rename can't cross file systems
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to write %s", path)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Chmod(perm)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to write %s", path)
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to replace %s", path)
+	}
+	return backup, nil
+}
+
+func (a *App) configMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate [path]",
+		Short: "Upgrade a config file to the current version, keeping a backup",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := a.root()
+			path := ""
+			if len(args) > 0 {
+				path = args[0]
+			} else if root.config != nil {
+				path, _ = root.configFile()
+			}
+			if path == "" {
+				return errors.New("no config file to migrate")
+			}
+
+			settings, err := readConfigFile(path)
+			if err != nil {
+				return err
+			}
+			version, err := root.migrateConfig(settings)
+			if err != nil {
+				return errors.Wrapf(err, "unable to migrate config file %s", path)
+			}
+			latest := root.configVersion()
+			if version == latest {
+				fmt.Fprintf(cmd.OutOrStdout(), "Config file %s is already at version %d\n", path, latest)
+				return nil
+			}
+
+			b, err := encodeConfig(withVersion(mapSection(settings), latest), formatOf(path))
+			if err != nil {
+				return err
+			}
+			backup, err := replaceFile(path, b)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Migrated config file %s from version %d to %d, backup written to %s\n", path, version, latest, backup)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,237 @@
+package ezcli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// migrateApp renames host to server.host in version 1 and timeout to server.timeout in version 2
+func migrateApp(host, timeout *string) *App {
+	app := New(&cobra.Command{Use: "tool"}, AppStrictConfig())
+	app.StringVar(host, "server.host", "", "usage")
+	app.StringVar(timeout, "server.timeout", "", "usage")
+	app.RegisterMigration(0, 1, func(settings map[string]any) error {
+		settings["server"] = map[string]any{"host": settings["host"]}
+		delete(settings, "host")
+		return nil
+	})
+	app.RegisterMigration(1, 2, func(settings map[string]any) error {
+		server, ok := settings["server"].(map[string]any)
+		if !ok {
+			return errors.New("missing server section")
+		}
+		server["timeout"] = settings["timeout"]
+		delete(settings, "timeout")
+		return nil
+	})
+	return app
+}
+
+func TestApp_Migration(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"unversioned", "host: example.com\ntimeout: 5s\n"},
+		{"version 1", "version: 1\nserver:\n  host: example.com\ntimeout: 5s\n"},
+		{"current", "version: 2\nserver:\n  host: example.com\n  timeout: 5s\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), test.config)
+			var host, timeout string
+			app := migrateApp(&host, &timeout)
+			app.Init(path, "")
+
+			err := app.load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if host != "example.com" || timeout != "5s" {
+				t.Errorf("expected 'example.com' and '5s' got '%s' and '%s'", host, timeout)
+			}
+		})
+	}
+}
+
+func TestApp_MigrationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"newer", "version: 3\n", "version 3 is newer than the latest version 2"},
+		{"not a number", "version: one\n", "version must be a whole number"},
+		{"fraction", "version: 1.5\n", "version must be a whole number"},
+		{"failed migration", "version: 1\n", "unable to migrate from version 1 to 2: missing server section"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), test.config)
+			var host, timeout string
+			app := migrateApp(&host, &timeout)
+			app.Init(path, "")
+
+			err := app.load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), path) {
+				t.Errorf("expected '%s' in %s got '%v'", test.expected, path, err)
+			}
+		})
+	}
+}
+
+func TestApp_MigrationFragments(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		fragment string
+		expected string
+	}{
+		{"unversioned fragment", "version: 2\nserver:\n  host: example.com\n", "server:\n  timeout: 5s\n", ""},
+		{"same version", "version: 2\nserver:\n  host: example.com\n", "version: 2\nserver:\n  timeout: 5s\n", ""},
+		{"include", "version: 2\ninclude: conf.d/10.yaml\nserver:\n  host: example.com\n", "server:\n  timeout: 5s\n", ""},
+		{"different version", "version: 2\nserver:\n  host: example.com\n", "version: 1\ntimeout: 5s\n", "version 1 doesn't match version 2 of the file it belongs to"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeFile(t, filepath.Join(dir, "tool.yaml"), test.config)
+			fragment := writeFile(t, filepath.Join(dir, confDir, "10.yaml"), test.fragment)
+			var host, timeout string
+			app := migrateApp(&host, &timeout)
+			app.Init(path, "")
+
+			err := app.load()
+			if test.expected != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expected) || !strings.Contains(err.Error(), fragment) {
+					t.Errorf("expected '%s' in %s got '%v'", test.expected, fragment, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if host != "example.com" || timeout != "5s" {
+				t.Errorf("expected 'example.com' and '5s' got '%s' and '%s'", host, timeout)
+			}
+		})
+	}
+}
+
+func TestApp_RegisterMigrationPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to int
+	}{
+		{"backwards", 2, 1},
+		{"same version", 1, 1},
+		{"duplicate", 0, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			var host, timeout string
+			app := migrateApp(&host, &timeout)
+			app.RegisterMigration(test.from, test.to, func(map[string]any) error { return nil })
+		})
+	}
+}
+
+func TestApp_ConfigMigrate(t *testing.T) {
+	for _, format := range []string{formatYAML, formatTOML, formatJSON} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tool."+format)
+			original := map[string]string{
+				formatYAML: "host: example.com\ntimeout: 5s\nextra:\n  list: [a, b]\n",
+				formatTOML: "host = \"example.com\"\ntimeout = \"5s\"\n[extra]\nlist = [\"a\", \"b\"]\n",
+				formatJSON: `{"host":"example.com","timeout":"5s","extra":{"list":["a","b"]}}`,
+			}[format]
+			writeFile(t, path, original)
+
+			var host, timeout string
+			app := migrateApp(&host, &timeout)
+			app.opts.unknownKeys = unknownKeysIgnore
+			app.AddConfigCommands()
+			out, err := runCmd(t, app, "config", "migrate", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, "from version 0 to 2") {
+				t.Errorf("expected migration message got '%s'", out)
+			}
+
+			backup, err := os.ReadFile(path + ".bak")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(backup) != original {
+				t.Errorf("expected backup '%s' got '%s'", original, backup)
+			}
+			settings, err := readConfigFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version, _ := settingsVersion(settings); version != 2 {
+				t.Errorf("expected version 2 got '%v'", settings[versionKey])
+			}
+			server := settings["server"].(map[string]any)
+			if server["host"] != "example.com" || server["timeout"] != "5s" {
+				t.Errorf("expected migrated server section got '%v'", server)
+			}
+			if _, ok := settings["extra"].(map[string]any)["list"]; !ok {
+				t.Errorf("expected other keys to be kept got '%v'", settings)
+			}
+
+			// The migrated file loads and is already current
+			app = migrateApp(&host, &timeout)
+			app.opts.unknownKeys = unknownKeysIgnore
+			app.AddConfigCommands()
+			app.Init(path, "")
+			out, err = runCmd(t, app, "config", "migrate")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(out, "already at version 2") {
+				t.Errorf("expected file to be current got '%s'", out)
+			}
+			if host != "example.com" || timeout != "5s" {
+				t.Errorf("expected 'example.com' and '5s' got '%s' and '%s'", host, timeout)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigInitVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool.yaml")
+	var host, timeout string
+	app := migrateApp(&host, &timeout)
+	app.AddConfigCommands()
+	_, err := runCmd(t, app, "config", "init", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "version: 2\n") {
+		t.Errorf("expected current version in '%s'", b)
+	}
+}
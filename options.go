@@ -2,11 +2,18 @@ package ezcli
 
 type appOptFn func(*AppOpts)
 
+// AppOpts are the behaviours that can be applied to an App and its children
 type AppOpts struct {
-	useConfig bool
-	envPrefix string
+	useConfig   bool
+	envPrefix   string
+	providers   []ConfigProvider
+	envFiles    *EnvFileOpts
+	unknownKeys unknownKeyMode
+	profiles    bool
+	keyFile     string
 }
 
+// AppUseConfig reads a config file named after the command, see App.Init
 func AppUseConfig() appOptFn {
 	return func(opts *AppOpts) {
 		opts.useConfig = true
@@ -14,22 +21,77 @@ func AppUseConfig() appOptFn {
 
 }
 
+// AppEnvPrefix binds every option to the environment variable <PREFIX>_<KEY>
+// Dashes and dots are replaced with underscores, child Apps inherit the prefix
 func AppEnvPrefix(prefix string) appOptFn {
 	return func(opts *AppOpts) {
 		opts.envPrefix = prefix
 	}
 }
 
+// AppConfigProvider adds a provider that is merged over the config files when the App loads
+// Providers take priority over files in the order they are added, flags and environment variables still take priority
+func AppConfigProvider(provider ConfigProvider) appOptFn {
+	return func(opts *AppOpts) {
+		opts.providers = append(opts.providers, provider)
+	}
+}
+
+// AppEnvFiles loads dotenv files before environment variables are read
+// This adds a repeatable --env-file flag and a --clean-env flag to ignore the inherited environment
+func AppEnvFiles(envFileOpts EnvFileOpts) appOptFn {
+	return func(opts *AppOpts) {
+		opts.envFiles = &envFileOpts
+	}
+}
+
+// AppStrictConfig fails to load config containing keys that don't belong to an option
+func AppStrictConfig() appOptFn {
+	return func(opts *AppOpts) {
+		opts.unknownKeys = unknownKeysError
+	}
+}
+
+// AppLenientConfig warns about config keys that don't belong to an option
+func AppLenientConfig() appOptFn {
+	return func(opts *AppOpts) {
+		opts.unknownKeys = unknownKeysWarn
+	}
+}
+
+// AppProfiles adds a --profile flag to select a section of the config's profiles key
+// The selected profile is merged over the rest of the config, a profile can extend another
+func AppProfiles() appOptFn {
+	return func(opts *AppOpts) {
+		opts.profiles = true
+	}
+}
+
+// AppConfigKeyFile reads the key for encrypted config values from a file holding a base64 AES key
+// The <APP>_CONFIG_KEY and <APP>_CONFIG_KEY_FILE environment variables take priority
+func AppConfigKeyFile(path string) appOptFn {
+	return func(opts *AppOpts) {
+		opts.keyFile = path
+	}
+}
+
 type varOptFn func(*VarOpts)
 
 // VarOpts are the available behaviours that can be applied to each command option
 type VarOpts struct {
-	Name         string // Name of the command eg: verbose will be --verbose
-	ShortName    string // Shorthand name eg: -v for --verbose
-	DefaultValue any    // Defaults to the nil value of the type
-	Usage        string //
-	Persistent   bool   // Option will persist to sub-commands
-	Env          string // If not "" - will bind the option to the environment variable
+	Name         string   // Name of the command eg: verbose will be --verbose
+	ShortName    string   // Shorthand name eg: -v for --verbose
+	DefaultValue any      // Defaults to the nil value of the type
+	Usage        string   //
+	Persistent   bool     // Option will persist to sub-commands
+	Env          string   // If not "" - will bind the option to the environment variable
+	SharedKey    bool     // Child Apps read the option from the top level config key rather than their own section
+	Sensitive    bool     // Value is redacted whenever ezcli prints it and can be read from the file at <ENV>_FILE
+	DefaultPort  uint16   // Port used by HostPort values that don't include one
+	URLSchemes   []string // Schemes allowed in *url.URL values, any scheme when empty
+	TimeLayout   string   // Layout of time.Time values, defaults to time.RFC3339
+
+	envFromName bool // Bind to the upper cased name when the App has no env prefix, from an empty env tag
 }
 
 func defaultVarOpts() *VarOpts {
@@ -78,3 +140,48 @@ func VarEnv(name string) varOptFn {
 		opts.Env = name
 	}
 }
+
+// varEnvFromName binds the option to its prefixed environment variable, or its upper cased name without a prefix
+func varEnvFromName() varOptFn {
+	return func(opts *VarOpts) {
+		opts.envFromName = true
+	}
+}
+
+// VarSharedKey reads the option from the top level config key in child Apps
+// rather than the section named after the child's command
+func VarSharedKey() varOptFn {
+	return func(opts *VarOpts) {
+		opts.SharedKey = true
+	}
+}
+
+// VarSensitive redacts the option's value whenever ezcli prints it
+// The value can also be read from the file named by the <ENV>_FILE environment variable
+func VarSensitive() varOptFn {
+	return func(opts *VarOpts) {
+		opts.Sensitive = true
+	}
+}
+
+// VarDefaultPort is the port used by HostPort values that don't include one
+func VarDefaultPort(port uint16) varOptFn {
+	return func(opts *VarOpts) {
+		opts.DefaultPort = port
+	}
+}
+
+// VarURLSchemes restricts *url.URL values to the schemes, eg: VarURLSchemes("http", "https")
+func VarURLSchemes(schemes ...string) varOptFn {
+	return func(opts *VarOpts) {
+		opts.URLSchemes = schemes
+	}
+}
+
+// VarTimeLayout sets the layout time.Time values are parsed with, eg: VarTimeLayout("2006-01-02")
+// RFC3339 is always accepted as times are written to config files in that layout
+func VarTimeLayout(layout string) varOptFn {
+	return func(opts *VarOpts) {
+		opts.TimeLayout = layout
+	}
+}
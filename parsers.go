@@ -1,6 +1,15 @@
 package ezcli
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,3 +46,344 @@ func parseDurationSlice(s string) ([]time.Duration, error) {
 	}
 	return durations, nil
 }
+
+// parseDotenv reads KEY=value lines from a dotenv file
+// Blank lines, # comments and a leading "export " are ignored
+// Double quoted values support escapes, single quoted values are taken literally
+func parseDotenv(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, errors.Errorf("line %d: expected KEY=value", lineNum)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, errors.Errorf("line %d: invalid double quoted value for %s", lineNum, key)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'"):
+			if len(value) < 2 || !strings.HasSuffix(value, "'") {
+				return nil, errors.Errorf("line %d: invalid single quoted value for %s", lineNum, key)
+			}
+			value = value[1 : len(value)-1]
+		default:
+			// Remove any trailing comment
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		env[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "unable to read")
+	}
+	return env, nil
+}
+
+// splitList splits a flag value "[a,b]" on commas or an environment variable "a b" on whitespace
+func splitList(s string) []string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	if s == "" {
+		return []string{}
+	}
+	if strings.Contains(s, ",") {
+		items := strings.Split(s, ",")
+		for i, item := range items {
+			items[i] = strings.TrimSpace(item)
+		}
+		return items
+	}
+	return strings.Fields(s)
+}
+
+// parseSlice parses each item of a flag or environment variable string or a config list
+// Errors name the item that failed to parse
+func parseSlice[T any](v any, parse func(string) (T, error)) ([]T, error) {
+	var items []string
+	switch val := v.(type) {
+	case nil:
+		items = []string{}
+	case string:
+		items = splitList(val)
+	default:
+		// Config lists and Viper's own flag slices
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Slice {
+			items = []string{formatItem(val)}
+			break
+		}
+		items = make([]string, rv.Len())
+		for i := range items {
+			items[i] = formatItem(rv.Index(i).Interface())
+		}
+	}
+
+	parsed := make([]T, len(items))
+	for i, item := range items {
+		var err error
+		parsed[i], err = parse(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse item %d %q", i+1, item)
+		}
+	}
+	return parsed, nil
+}
+
+// parseMap parses "k=v,k2=v2" from a flag or environment variable, or a config object
+// Errors name the key that failed to parse
+func parseMap[T any](v any, parse func(string) (T, error)) (map[string]T, error) {
+	items := make(map[string]string)
+	switch val := v.(type) {
+	case nil:
+	case string:
+		val = strings.TrimSpace(val)
+		if strings.HasPrefix(val, "[") && strings.HasSuffix(val, "]") {
+			val = val[1 : len(val)-1]
+		}
+		if val == "" {
+			break
+		}
+		for _, item := range strings.Split(val, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+			if !ok {
+				return nil, errors.Errorf("unable to parse %q, expected key=value", item)
+			}
+			items[key] = value
+		}
+	default:
+		// Config objects and Viper's own flag maps
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Map {
+			return nil, errors.Errorf("unable to parse %v, expected key=value pairs", val)
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			items[fmt.Sprint(iter.Key().Interface())] = formatItem(iter.Value().Interface())
+		}
+	}
+
+	parsed := make(map[string]T, len(items))
+	for key, item := range items {
+		var err error
+		parsed[key], err = parse(item)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse key %q", key)
+		}
+	}
+	return parsed, nil
+}
+
+// formatItem formats a config list item, JSON numbers are floats so they're written without exponents
+func formatItem(item any) string {
+	switch val := item.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case time.Time:
+		// YAML reads unquoted timestamps as times
+		return val.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(item)
+}
+
+func parseString(s string) (string, error) {
+	return s, nil
+}
+
+func parseInt64(s string) (int64, error) {
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func parseUint(s string) (uint, error) {
+	u, err := strconv.ParseUint(s, 10, 0)
+	return uint(u), err
+}
+
+func parseIP(s string) (net.IP, error) {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.Errorf("invalid IP address %q, expected IPv4 or IPv6 such as 192.0.2.1 or 2001:db8::1", s)
+	}
+	return ip, nil
+}
+
+func parseAddr(s string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return addr, errors.Errorf("invalid IP address %q, expected IPv4 or IPv6 such as 192.0.2.1 or 2001:db8::1", s)
+	}
+	return addr, nil
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(s)
+	if err != nil {
+		return prefix, errors.Errorf("invalid CIDR %q, expected an IP address and prefix length such as 192.0.2.0/24 or 2001:db8::/32", s)
+	}
+	return prefix, nil
+}
+
+func parseAddrPort(s string) (netip.AddrPort, error) {
+	addrPort, err := netip.ParseAddrPort(s)
+	if err != nil {
+		return addrPort, errors.Errorf("invalid address %q, expected an IP address and port such as 192.0.2.1:80 or [2001:db8::1]:80", s)
+	}
+	return addrPort, nil
+}
+
+// parseIPNet parses a CIDR into its network, host bits are masked
+func parseIPNet(s string) (net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		return net.IPNet{}, errors.Errorf("invalid CIDR %q, expected an IP address and prefix length such as 192.0.2.0/24 or 2001:db8::/32", s)
+	}
+	return *ipNet, nil
+}
+
+func formatInt64(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
+
+func formatUint(u uint) string {
+	return strconv.FormatUint(uint64(u), 10)
+}
+
+func formatIP(ip net.IP) string {
+	return ip.String()
+}
+
+// formatText formats netip values, the zero value is empty rather than "invalid IP"
+func formatText[T interface{ IsValid() bool }](v T) string {
+	if !v.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func formatIPNet(ipNet net.IPNet) string {
+	if ipNet.IP == nil {
+		return ""
+	}
+	return ipNet.String()
+}
+
+// parseURL parses an absolute URL, when schemes are given the URL must use one of them
+func parseURL(schemes []string) func(string) (*url.URL, error) {
+	return func(s string) (*url.URL, error) {
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme == "" {
+			return nil, errors.Errorf("invalid URL %q, expected an absolute URL such as https://example.com/path", s)
+		}
+		if len(schemes) == 0 {
+			return u, nil
+		}
+		for _, scheme := range schemes {
+			if strings.EqualFold(u.Scheme, scheme) {
+				return u, nil
+			}
+		}
+		return nil, errors.Errorf("invalid URL %q, expected a scheme of %s", s, strings.Join(schemes, ", "))
+	}
+}
+
+func formatURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func parseRegexp(s string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid regular expression %q", s)
+	}
+	return re, nil
+}
+
+func formatRegexp(re *regexp.Regexp) string {
+	if re == nil {
+		return ""
+	}
+	return re.String()
+}
+
+// parseTime parses a time in the layout, defaulting to RFC3339
+// RFC3339 is always accepted as that's how times are written to config files
+func parseTime(layout string) func(string) (time.Time, error) {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return func(s string) (time.Time, error) {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, nil
+		}
+		return time.Time{}, errors.Errorf("invalid time %q, expected the layout %s", s, layout)
+	}
+}
+
+// formatTime formats a time in the layout, defaulting to RFC3339, the zero time is empty
+func formatTime(layout string) func(time.Time) string {
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(layout)
+	}
+}
+
+func parseLocation(s string) (*time.Location, error) {
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, errors.Errorf("invalid time zone %q, expected a name from the IANA time zone database such as Europe/London or UTC", s)
+	}
+	return loc, nil
+}
+
+func formatLocation(loc *time.Location) string {
+	if loc == nil {
+		return ""
+	}
+	return loc.String()
+}
+
+func parseFloat64(s string) (float64, error) {
+	return strconv.ParseFloat(s, 64)
+}
+
+func parseFloat32(s string) (float32, error) {
+	f, err := strconv.ParseFloat(s, 32)
+	return float32(f), err
+}
+
+func formatFloat64(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func formatFloat32(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
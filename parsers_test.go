@@ -2,6 +2,7 @@ package ezcli
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -31,3 +32,135 @@ func TestParseDurationSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		out     map[string]string
+		wantErr bool
+	}{
+		{"simple", "KEY=value", map[string]string{"KEY": "value"}, false},
+		{"comments and blanks", "# comment\n\nKEY=value # trailing\n", map[string]string{"KEY": "value"}, false},
+		{"export", "export KEY=value", map[string]string{"KEY": "value"}, false},
+		{"double quoted", `KEY="line one\nline # two"`, map[string]string{"KEY": "line one\nline # two"}, false},
+		{"single quoted", `KEY='$literal\n'`, map[string]string{"KEY": `$literal\n`}, false},
+		{"empty", "KEY=", map[string]string{"KEY": ""}, false},
+		{"equals in value", "KEY=a=b", map[string]string{"KEY": "a=b"}, false},
+		{"missing equals", "KEY", nil, true},
+		{"space in key", "MY KEY=value", nil, true},
+		{"unterminated quote", `KEY="value`, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseDotenv(strings.NewReader(test.in))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %t got '%v'", test.wantErr, err)
+			}
+			if !test.wantErr && !reflect.DeepEqual(got, test.out) {
+				t.Errorf("got '%v' expected '%v'", got, test.out)
+			}
+		})
+	}
+}
+
+func TestParseSlice(t *testing.T) {
+	tests := []struct {
+		name string
+		in   any
+		out  []float64
+		err  string
+	}{
+		{"flag", "[0.5,1e-10]", []float64{0.5, 1e-10}, ""},
+		{"env", "0.5  2E3", []float64{0.5, 2000}, ""},
+		{"config", []any{0.5, 2}, []float64{0.5, 2}, ""},
+		{"empty flag", "[]", []float64{}, ""},
+		{"unset", nil, []float64{}, ""},
+		{"invalid item", "1,two,3", nil, `unable to parse item 2 "two"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseSlice(test.in, parseFloat64)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got error '%v' expected '%s'", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.out) {
+				t.Errorf("got '%v' expected '%v'", got, test.out)
+			}
+		})
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		schemes []string
+		err     string
+	}{
+		{"https://example.com/path", nil, ""},
+		{"postgres://db:5432/app", nil, ""},
+		{"HTTPS://example.com", []string{"http", "https"}, ""},
+		{"example.com", nil, `invalid URL "example.com", expected an absolute URL`},
+		{"://bad", nil, `invalid URL "://bad"`},
+		{"ftp://example.com", []string{"http", "https"}, `invalid URL "ftp://example.com", expected a scheme of http, https`},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			u, err := parseURL(test.schemes)(test.in)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected '%s' got '%v'", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.EqualFold(u.String(), test.in) {
+				t.Errorf("expected '%s' got '%s'", test.in, u)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		layout string
+		out    time.Time
+		err    string
+	}{
+		{"2024-03-01T00:00:00Z", "", date, ""},
+		{"2024-03-01T02:00:00+02:00", "", date, ""},
+		{"2024-03-01", "2006-01-02", date, ""},
+		{"2024-03-01T00:00:00Z", "2006-01-02", date, ""},
+		{"2024-03-01", "", time.Time{}, `invalid time "2024-03-01", expected the layout ` + time.RFC3339},
+		{"01/03/2024", "2006-01-02", time.Time{}, `invalid time "01/03/2024", expected the layout 2006-01-02`},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			out, err := parseTime(test.layout)(test.in)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("expected '%s' got '%v'", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !out.Equal(test.out) {
+				t.Errorf("expected '%s' got '%s'", test.out, out)
+			}
+		})
+	}
+}
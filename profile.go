@@ -0,0 +1,114 @@
+package ezcli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	profileFlag      = "profile"
+	profileEnvSuffix = "_PROFILE"
+	profilesKey      = "profiles"
+	extendsKey       = "extends"
+)
+
+// addProfileFlag adds the --profile flag and names the active profile in help
+func (a *App) addProfileFlag() {
+	a.Cmd.PersistentFlags().StringVar(&a.profile, profileFlag, "",
+		fmt.Sprintf("config profile to use, can be set with %s", a.profileEnv()))
+
+	help := a.Cmd.HelpFunc()
+	a.Cmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		if profile := a.activeProfile(); profile != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Active profile: %s\n\n", profile)
+		}
+		help(cmd, args)
+	})
+}
+
+// profileEnv is the environment variable that can select a profile
+func (a *App) profileEnv() string {
+	return a.appEnv(profileEnvSuffix)
+}
+
+// activeProfile is the profile from the --profile flag or environment variable
+func (a *App) activeProfile() string {
+	root := a.root()
+	if !root.opts.profiles {
+		return ""
+	}
+	if root.profile != "" {
+		return root.profile
+	}
+	return os.Getenv(root.profileEnv())
+}
+
+// applyProfile merges the active profile over the rest of the settings
+// A profile can extend another profile, which is applied first
+func (a *App) applyProfile(load *configLoad) error {
+	settings := load.settings
+	name := a.activeProfile()
+	if name == "" {
+		return nil
+	}
+	profiles, _ := settings[profilesKey].(map[string]any)
+
+	// Follow the chain of profiles back to the first
+	chain := make([]string, 0)
+	seen := make(map[string]bool)
+	for profile := strings.ToLower(name); profile != ""; {
+		if seen[profile] {
+			return errors.Errorf("profile %s extends itself through %s", profile, strings.Join(append(chain, profile), " -> "))
+		}
+		seen[profile] = true
+		chain = append(chain, profile)
+
+		values, ok := profiles[profile].(map[string]any)
+		if !ok {
+			return errors.Errorf("profile %s not found in config, available profiles: %s", profile, profileNames(profiles))
+		}
+		profile, _ = values[extendsKey].(string)
+		profile = strings.ToLower(profile)
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		profile := chain[i]
+		values := copyMap(profiles[profile].(map[string]any))
+		delete(values, extendsKey)
+		mergeMaps(settings, values)
+
+		// Keep where each value was originally set
+		for _, key := range flattenKeys(values, "") {
+			source := load.sources[profilesKey+"."+profile+"."+key]
+			source.Detail = fmt.Sprintf("%s (profile %s)", source.Detail, profile)
+			load.sources[key] = source
+		}
+	}
+	return nil
+}
+
+func profileNames(profiles map[string]any) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// profileKey is the key a profile sets, profiles.<name>.<key>, or false if it isn't in a profile
+func profileKey(key string) (string, bool) {
+	parts := strings.SplitN(key, ".", 3)
+	if len(parts) != 3 || parts[0] != profilesKey {
+		return "", false
+	}
+	return parts[2], true
+}
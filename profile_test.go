@@ -0,0 +1,153 @@
+package ezcli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+const profileConfig = `
+host: localhost
+port: 80
+debug: true
+profiles:
+  staging:
+    host: staging.example.com
+    debug: false
+  prod:
+    extends: staging
+    host: prod.example.com
+  loop:
+    extends: loop
+`
+
+type profileVars struct {
+	host  string
+	port  int
+	debug bool
+}
+
+func profileApp(t *testing.T, v *profileVars, optFns ...appOptFn) (*App, string) {
+	t.Helper()
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), profileConfig)
+	t.Setenv("TOOL_PROFILE", "")
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}}, append(optFns, AppProfiles())...)
+	app.StringVar(&v.host, "host", "", "usage")
+	app.IntVar(&v.port, "port", 0, "usage")
+	app.BoolVar(&v.debug, "debug", false, "usage")
+	app.Init(path, "")
+	return app, path
+}
+
+func TestApp_Profiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected profileVars
+	}{
+		{"base", nil, "", profileVars{"localhost", 80, true}},
+		{"flag", []string{"--profile=staging"}, "", profileVars{"staging.example.com", 80, false}},
+		{"env", nil, "staging", profileVars{"staging.example.com", 80, false}},
+		{"extends", []string{"--profile=prod"}, "staging", profileVars{"prod.example.com", 80, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := profileVars{}
+			app, path := profileApp(t, &got)
+			t.Setenv("TOOL_PROFILE", test.env)
+			_, err := runCmd(t, app, test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %+v got %+v", test.expected, got)
+			}
+			if test.name == "extends" {
+				// Values keep the profile they were set by
+				expected := Source{Kind: SourceFile, Detail: path + " (profile staging)"}
+				if source := app.Source("debug"); source != expected {
+					t.Errorf("expected source '%s' got '%s'", expected, source)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_ProfileErrors(t *testing.T) {
+	tests := []struct {
+		profile string
+		msg     string
+	}{
+		{"missing", "profile missing: profile missing not found in config, available profiles: loop, prod, staging"},
+		{"loop", "profile loop: profile loop extends itself through loop -> loop"},
+	}
+	for _, test := range tests {
+		t.Run(test.profile, func(t *testing.T) {
+			app, _ := profileApp(t, &profileVars{})
+			err := app.Cmd.ParseFlags([]string{"--profile=" + test.profile})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = app.loadErr(app.load())
+			if err == nil || err.Error() != test.msg {
+				t.Errorf("expected error '%s' got '%v'", test.msg, err)
+			}
+		})
+	}
+}
+
+func TestApp_ProfileHelp(t *testing.T) {
+	app, _ := profileApp(t, &profileVars{})
+	out, err := runCmd(t, app, "--profile=prod", "--help")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "Active profile: prod\n") {
+		t.Errorf("expected help to name the active profile:\n%s", out)
+	}
+}
+
+func TestApp_ProfileStrict(t *testing.T) {
+	app, path := profileApp(t, &profileVars{}, AppStrictConfig())
+	writeFile(t, path, "profiles:\n  prod:\n    extends: staging\n    hots: prod.example.com\n  staging:\n    host: staging.example.com\n")
+	err := app.load()
+	if err == nil {
+		t.Fatal("expected an error for an unknown key")
+	}
+	msg := `unknown config key "profiles.prod.hots" in ` + path + `, did you mean "profiles.prod.host"?`
+	if err.Error() != msg {
+		t.Errorf("expected error '%s' got '%v'", msg, err)
+	}
+}
+
+func TestApp_ProfileSchema(t *testing.T) {
+	app, _ := profileApp(t, &profileVars{})
+	b, err := app.JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := struct {
+		Properties struct {
+			Profiles struct {
+				AdditionalProperties struct {
+					Properties map[string]any
+				}
+			}
+		}
+	}{}
+	err = json.Unmarshal(b, &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	properties := schema.Properties.Profiles.AdditionalProperties.Properties
+	for _, key := range []string{"host", "port", "debug", extendsKey} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("expected profile schema to have '%s' got %v", key, properties)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package ezcli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultHTTPTimeout  = 10 * time.Second
+)
+
+// defaultHTTPClient is used by HTTPProviders without a Client so a slow server can't hold up loading
+var defaultHTTPClient = &http.Client{Timeout: defaultHTTPTimeout}
+
+// ConfigProvider supplies config from outside of the config files, eg: a remote key value store
+type ConfigProvider interface {
+	// Load returns the provider's config as nested maps
+	Load(ctx context.Context) (map[string]any, error)
+}
+
+// ConfigWatcher is a ConfigProvider that can report changes, it is used by App.WatchConfig
+type ConfigWatcher interface {
+	// Watch starts watching for changes, onChange is called after every change until ctx is done
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// providerName describes a provider in sources, Stringers can name themselves
+func providerName(provider ConfigProvider) string {
+	if stringer, ok := provider.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", provider)
+}
+
+// loadProviders merges every provider's config into the settings
+func (a *App) loadProviders(load *configLoad) error {
+	ctx := a.Cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, provider := range a.opts.providers {
+		name := providerName(provider)
+		values, err := provider.Load(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "unable to load config from %s", name)
+		}
+		a.mergeConfig(load, lowerKeys(values), Source{Kind: SourceProvider, Detail: name})
+	}
+	return nil
+}
+
+// lowerKeys copies nested maps with every key lower cased, matching Viper's case insensitivity
+func lowerKeys(m map[string]any) map[string]any {
+	lowered := make(map[string]any, len(m))
+	for key, val := range m {
+		if nested, ok := val.(map[string]any); ok {
+			val = lowerKeys(nested)
+		}
+		lowered[strings.ToLower(key)] = val
+	}
+	return lowered
+}
+
+// HTTPProvider loads config from a JSON object served at a URL
+type HTTPProvider struct {
+	URL      string
+	Client   *http.Client  // Defaults to a client with a 10s timeout
+	Interval time.Duration // How often Watch requests the URL, defaults to 30s
+}
+
+func (p *HTTPProvider) String() string {
+	return p.URL
+}
+
+func (p *HTTPProvider) Load(ctx context.Context) (map[string]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.URL, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := p.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to request config")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+
+	values := make(map[string]any)
+	err = json.NewDecoder(resp.Body).Decode(&values)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to decode config")
+	}
+	return values, nil
+}
+
+// Watch requests the URL every Interval and calls onChange when the config is different
+func (p *HTTPProvider) Watch(ctx context.Context, onChange func()) error {
+	last, err := p.Load(ctx)
+	if err != nil {
+		return err
+	}
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				values, err := p.Load(ctx)
+				// Try again next time, the App keeps its current values
+				if err != nil || reflect.DeepEqual(last, values) {
+					continue
+				}
+				last = values
+				onChange()
+			}
+		}
+	}()
+	return nil
+}
+
+// MemoryProvider holds config in memory, it can stand in for a remote store when testing
+type MemoryProvider struct {
+	mu       sync.Mutex
+	values   map[string]any
+	watchers map[int]func()
+	nextID   int
+}
+
+func NewMemoryProvider(values map[string]any) *MemoryProvider {
+	return &MemoryProvider{
+		values:   copyMap(values),
+		watchers: make(map[int]func()),
+	}
+}
+
+func (p *MemoryProvider) String() string {
+	return "memory"
+}
+
+func (p *MemoryProvider) Load(ctx context.Context) (map[string]any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return copyMap(p.values), nil
+}
+
+// Set replaces the provider's config and notifies any watchers
+func (p *MemoryProvider) Set(values map[string]any) {
+	p.mu.Lock()
+	p.values = copyMap(values)
+	watchers := make([]func(), 0, len(p.watchers))
+	for _, onChange := range p.watchers {
+		watchers = append(watchers, onChange)
+	}
+	p.mu.Unlock()
+
+	for _, onChange := range watchers {
+		onChange()
+	}
+}
+
+func (p *MemoryProvider) Watch(ctx context.Context, onChange func()) error {
+	p.mu.Lock()
+	id := p.nextID
+	p.nextID++
+	p.watchers[id] = onChange
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		delete(p.watchers, id)
+		p.mu.Unlock()
+	}()
+	return nil
+}
+
+// copyMap deep copies nested maps so callers can't change our values
+func copyMap(m map[string]any) map[string]any {
+	copied := make(map[string]any, len(m))
+	for key, val := range m {
+		if nested, ok := val.(map[string]any); ok {
+			val = copyMap(nested)
+		}
+		copied[key] = val
+	}
+	return copied
+}
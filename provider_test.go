@@ -0,0 +1,219 @@
+package ezcli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApp_ConfigProvider(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "file: from-file\nprovider: from-file\nenv: from-file\n")
+	t.Setenv("TOOL_ENV", "from-env")
+	provider := NewMemoryProvider(map[string]any{
+		"Provider": "from-provider",
+		"env":      "from-provider",
+	})
+
+	app := New(&cobra.Command{Use: "tool"}, AppConfigProvider(provider))
+	var file, fromProvider, env string
+	app.StringVar(&file, "file", "", "usage")
+	app.StringVar(&fromProvider, "provider", "", "usage")
+	app.genericVar(&env, VarName("env"), VarEnv("TOOL_ENV"))
+	app.Init(path, "")
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct{ expected, got string }{
+		{"from-file", file},
+		{"from-provider", fromProvider},
+		{"from-env", env},
+	} {
+		if test.expected != test.got {
+			t.Errorf("expected '%s' got '%s'", test.expected, test.got)
+		}
+	}
+	if source := app.Source("provider"); source != (Source{Kind: SourceProvider, Detail: "memory"}) {
+		t.Errorf("expected memory provider source got '%s'", source)
+	}
+}
+
+func TestApp_ConfigProviderProfile(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "host: from-file\nprofiles:\n  prod:\n    host: from-profile\n    port: 443\n")
+	t.Setenv("TOOL_PROFILE", "prod")
+	provider := NewMemoryProvider(map[string]any{"host": "from-provider"})
+
+	app := New(&cobra.Command{Use: "tool"}, AppConfigProvider(provider), AppProfiles())
+	var host string
+	var port int
+	app.StringVar(&host, "host", "", "usage")
+	app.IntVar(&port, "port", 0, "usage")
+	app.Init(path, "")
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host != "from-provider" || port != 443 {
+		t.Errorf("expected 'from-provider' '443' got '%s' '%d'", host, port)
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	var status int32 = http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+		fmt.Fprint(w, `{"server":{"port":8080}}`)
+	}))
+	defer server.Close()
+	provider := &HTTPProvider{URL: server.URL}
+
+	app := New(&cobra.Command{Use: "tool"}, AppConfigProvider(provider))
+	var port int
+	app.IntVar(&port, "server.port", 0, "usage")
+	app.Init("", "")
+
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 8080 {
+		t.Errorf("expected '8080' got '%d'", port)
+	}
+	if source := app.Source("server.port"); source.Detail != server.URL {
+		t.Errorf("expected source '%s' got '%s'", server.URL, source)
+	}
+
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	err = app.load()
+	if err == nil {
+		t.Error("expected an error for a bad status")
+	}
+}
+
+func TestApp_WatchConfigProvider(t *testing.T) {
+	provider := NewMemoryProvider(map[string]any{"name": "before"})
+	app := New(&cobra.Command{Use: "tool"}, AppConfigProvider(provider))
+	var name string
+	app.StringVar(&name, "name", "", "usage")
+	app.Init("", "")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan []ConfigChange, 1)
+	err = app.WatchConfig(ctx, func(changes []ConfigChange) {
+		changed <- changes
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider.Set(map[string]any{"name": "after"})
+	select {
+	case changes := <-changed:
+		if len(changes) != 1 || changes[0].New != "after" {
+			t.Errorf("unexpected changes %+v", changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+	app.RLock()
+	defer app.RUnlock()
+	if name != "after" {
+		t.Errorf("expected 'after' got '%s'", name)
+	}
+}
+
+func TestHTTPProvider_Watch(t *testing.T) {
+	var port int32 = 80
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"port":%d}`, atomic.LoadInt32(&port))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan struct{}, 1)
+	provider := &HTTPProvider{URL: server.URL, Interval: 10 * time.Millisecond}
+	err := provider.Watch(ctx, func() { changed <- struct{}{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atomic.StoreInt32(&port, 8080)
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("change was not seen")
+	}
+}
+
+// blockingProvider waits for release on every Load after the first
+type blockingProvider struct {
+	loads   int32
+	loading chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) Load(ctx context.Context) (map[string]any, error) {
+	if atomic.AddInt32(&p.loads, 1) > 1 {
+		p.loading <- struct{}{}
+		<-p.release
+	}
+	return map[string]any{"name": "value"}, nil
+}
+
+func TestApp_ReloadProviderUnlocked(t *testing.T) {
+	provider := &blockingProvider{loading: make(chan struct{}), release: make(chan struct{})}
+	app := New(&cobra.Command{Use: "tool"}, AppConfigProvider(provider))
+	var name string
+	app.StringVar(&name, "name", "", "usage")
+	app.Init("", "")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan error)
+	go func() {
+		_, err := app.reloadConfig()
+		reloaded <- err
+	}()
+	<-provider.loading
+
+	// Readers aren't blocked while the provider loads
+	locked := make(chan struct{})
+	go func() {
+		app.RLock()
+		app.RUnlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RLock waited for the provider to load")
+	}
+
+	close(provider.release)
+	if err := <-reloaded; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPProvider_DefaultTimeout(t *testing.T) {
+	if defaultHTTPClient.Timeout != defaultHTTPTimeout || defaultHTTPClient == http.DefaultClient {
+		t.Errorf("expected a client with a '%s' timeout got '%s'", defaultHTTPTimeout, defaultHTTPClient.Timeout)
+	}
+}
@@ -0,0 +1,172 @@
+package ezcli
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	// durationPattern matches strings accepted by time.ParseDuration
+	durationPattern = `^[-+]?(0|(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+)$`
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	ipType       = reflect.TypeOf(net.IP{})
+	ipNetType    = reflect.TypeOf(net.IPNet{})
+	hostPortType = reflect.TypeOf(HostPort{})
+	urlType      = reflect.TypeOf(&url.URL{})
+	regexpType   = reflect.TypeOf(&regexp.Regexp{})
+	locationType = reflect.TypeOf(&time.Location{})
+
+	// valueTypes are structs and pointers set as a single variable rather than a group of fields
+	valueTypes = map[reflect.Type]bool{
+		ipNetType:    true,
+		hostPortType: true,
+		urlType:      true,
+		regexpType:   true,
+		locationType: true,
+	}
+)
+
+// JSONSchema describes every variable in the App tree as a JSON Schema (draft 2020-12)
+// Child Apps are nested objects named after their command, matching the config file layout
+func (a *App) JSONSchema() ([]byte, error) {
+	root := a.root()
+	schema := sectionSchema(root.defaultsSection())
+	schema["$schema"] = jsonSchemaDraft
+	schema["title"] = root.Cmd.Name()
+	if root.Cmd.Short != "" {
+		schema["description"] = root.Cmd.Short
+	}
+	schema["properties"].(map[string]any)[includeKey] = map[string]any{
+		"description": "config files to merge over this one, relative globs are relative to this file",
+		"anyOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	if version := root.configVersion(); version > 0 {
+		schema["properties"].(map[string]any)[versionKey] = map[string]any{
+			"type":        "integer",
+			"description": "config file version",
+			"minimum":     0,
+			"maximum":     version,
+		}
+	}
+	if root.opts.profiles {
+		profile := sectionSchema(root.defaultsSection())
+		profile["properties"].(map[string]any)[extendsKey] = map[string]any{
+			"type":        "string",
+			"description": "profile to apply before this one",
+		}
+		schema["properties"].(map[string]any)[profilesKey] = map[string]any{
+			"type":                 "object",
+			"description":          "named sets of values selected with --" + profileFlag,
+			"additionalProperties": profile,
+		}
+	}
+
+	b, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encode schema")
+	}
+	return append(b, '\n'), nil
+}
+
+func sectionSchema(s *configSection) map[string]any {
+	properties := make(map[string]any)
+	for _, key := range s.keys {
+		property := typeSchema(key.v.value.Type())
+		if key.usage != "" {
+			property["description"] = key.usage
+		}
+		// Never publish sensitive defaults
+		if key.v.opts.Sensitive {
+			property["writeOnly"] = true
+		} else {
+			property["default"] = key.value
+		}
+		properties[key.name] = property
+	}
+	for _, section := range s.sections {
+		if section.empty() {
+			continue
+		}
+		property := sectionSchema(section)
+		if section.usage != "" {
+			property["description"] = section.usage
+		}
+		properties[section.name] = property
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// typeSchema describes the config value for a variable's type
+func typeSchema(t reflect.Type) map[string]any {
+	switch t {
+	case durationType:
+		return map[string]any{"type": "string", "pattern": durationPattern}
+	case ipType:
+		return map[string]any{
+			"type":  "string",
+			"anyOf": []any{map[string]any{"format": "ipv4"}, map[string]any{"format": "ipv6"}},
+		}
+	case ipNetType, hostPortType, locationType:
+		return map[string]any{"type": "string"}
+	case urlType:
+		return map[string]any{"type": "string", "format": "uri"}
+	case regexpType:
+		return map[string]any{"type": "string", "format": "regex"}
+	}
+
+	// Custom types are written as text
+	if isCustomType(t) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		bits := t.Bits()
+		return map[string]any{
+			"type":    "integer",
+			"minimum": int64(-1) << (bits - 1),
+			"maximum": int64(1)<<(bits-1) - 1,
+		}
+	case reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{
+			"type":    "integer",
+			"minimum": 0,
+			"maximum": uint64(math.MaxUint64) >> (64 - t.Bits()),
+		}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	}
+	// Accept anything we can't describe
+	return map[string]any{}
+}
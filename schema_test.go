@@ -0,0 +1,101 @@
+package ezcli
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApp_JSONSchema(t *testing.T) {
+	app := New(&cobra.Command{Use: "tool", Short: "a tool"})
+	var name, token string
+	var port uint16
+	var wait time.Duration
+	var tags []string
+	var value int8
+	app.StringVar(&name, "name", "default", "name to use")
+	app.genericVar(&token, VarName("token"), VarDefaultValue("s3cr3t"), VarSensitive())
+	app.genericVar(&port, VarName("server.port"), VarDefaultValue(uint16(80)))
+	app.DurationVar(&wait, "wait", 5*time.Second, "")
+	app.Var(&tags, "tags", []string{"a"}, "")
+	child := app.Child(New(&cobra.Command{Use: "child", Short: "a child"}))
+	child.genericVar(&value, VarName("value"))
+	app.AddConfigCommands()
+
+	out, err := runCmd(t, app, "config", "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := make(map[string]any)
+	err = json.Unmarshal([]byte(out), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Round trip through JSON to compare numbers as float64
+	expected := make(map[string]any)
+	err = json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "tool",
+		"description": "a tool",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "default": "default", "description": "name to use"},
+			"token": {"type": "string", "writeOnly": true},
+			"wait": {"type": "string", "default": "5s", "pattern": `+jsonString(durationPattern)+`},
+			"tags": {"type": "array", "items": {"type": "string"}, "default": ["a"]},
+			"include": {
+				"description": "config files to merge over this one, relative globs are relative to this file",
+				"anyOf": [{"type": "string"}, {"type": "array", "items": {"type": "string"}}]
+			},
+			"server": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"port": {"type": "integer", "minimum": 0, "maximum": 65535, "default": 80}
+				}
+			},
+			"child": {
+				"type": "object",
+				"description": "a child",
+				"additionalProperties": false,
+				"properties": {
+					"value": {"type": "integer", "minimum": -128, "maximum": 127, "default": 0}
+				}
+			}
+		}
+	}`), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("expected:\n%v\ngot:\n%v", expected, schema)
+	}
+}
+
+func TestDurationPattern(t *testing.T) {
+	pattern := regexp.MustCompile(durationPattern)
+	for _, d := range []string{"0", "5s", "-1.5h", "1h30m", "300ms", ".5us", "2µs"} {
+		if !pattern.MatchString(d) {
+			t.Errorf("expected '%s' to match", d)
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			t.Errorf("expected '%s' to be a duration", d)
+		}
+	}
+	for _, d := range []string{"", "5", "s", "5 s", "5d"} {
+		if pattern.MatchString(d) {
+			t.Errorf("expected '%s' not to match", d)
+		}
+	}
+}
+
+func jsonString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,81 @@
+package ezcli
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// redacted replaces sensitive values whenever ezcli prints them
+	redacted      = "******"
+	secretFileEnv = "_FILE"
+)
+
+// redact hides the value of sensitive variables, zero values are left as is
+func redact(opts *VarOpts, v any) any {
+	if !opts.Sensitive || v == nil || reflect.ValueOf(v).IsZero() {
+		return v
+	}
+	return redacted
+}
+
+// redactErr replaces an error that may contain the value of a sensitive variable
+func redactErr(opts *VarOpts, err error) error {
+	if !opts.Sensitive {
+		return err
+	}
+	return errors.Errorf("unable to parse %s from %s", opts.Name, redacted)
+}
+
+// loadSecretFiles reads sensitive variables from <ENV>_FILE when <ENV> is not set
+// This is how Docker and Kubernetes provide mounted secrets
+func (a *App) loadSecretFiles() error {
+	var err error
+	a.visit(func(app *App) {
+		for _, v := range app.vars {
+			if err != nil {
+				return
+			}
+			err = app.loadSecretFile(v)
+		}
+	})
+	return err
+}
+
+func (a *App) loadSecretFile(v *variable) error {
+	env := a.env(v.opts)
+	if !v.opts.Sensitive || env == "" || os.Getenv(env) != "" {
+		return nil
+	}
+	path := os.Getenv(env + secretFileEnv)
+	// Flags take priority over the environment
+	if path == "" || (v.flag != nil && v.flag.Changed) {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read %s from %s", v.opts.Name, env+secretFileEnv)
+	}
+	a.Viper.Set(a.key(v.opts), strings.TrimRight(string(b), "\r\n"))
+	return nil
+}
+
+// redactFlagErrors replaces flag parsing errors for sensitive variables, they include the value
+func (a *App) redactFlagErrors() {
+	next := a.Cmd.FlagErrorFunc()
+	a.Cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		a.visit(func(app *App) {
+			for _, v := range app.vars {
+				if v.opts.Sensitive && v.flag != nil && strings.Contains(err.Error(), fmt.Sprintf("--%s\" flag", v.flag.Name)) {
+					err = errors.Errorf("invalid argument %s for \"--%s\" flag", redacted, v.flag.Name)
+				}
+			}
+		})
+		return next(cmd, err)
+	})
+}
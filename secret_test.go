@@ -0,0 +1,85 @@
+package ezcli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApp_SensitiveFromFile(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "token"), "s3cr3t\n")
+	t.Setenv("TOKEN", "")
+	t.Setenv("TOKEN_FILE", path)
+
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}})
+	var token string
+	app.genericVar(&token, VarName("token"), VarEnv("TOKEN"), VarSensitive())
+	app.AddConfigCommands()
+
+	out, err := runCmd(t, app, "config", "show")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "s3cr3t" {
+		t.Errorf("expected value from file got '%s'", token)
+	}
+	if source := app.Source("token"); source.Detail != "TOKEN_FILE" {
+		t.Errorf("expected source 'TOKEN_FILE' got '%s'", source)
+	}
+	if strings.Contains(out, "s3cr3t") || !strings.Contains(out, redacted) {
+		t.Errorf("expected value to be redacted in:\n%s", out)
+	}
+}
+
+func TestApp_SensitiveFlagOverFile(t *testing.T) {
+	t.Setenv("TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}})
+	var token string
+	app.genericVar(&token, VarName("token"), VarEnv("TOKEN"), VarSensitive())
+
+	_, err := runCmd(t, app, "--token=flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "flag" {
+		t.Errorf("expected 'flag' got '%s'", token)
+	}
+}
+
+func TestApp_SensitiveHelp(t *testing.T) {
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}})
+	var token string
+	var pin int
+	app.genericVar(&token, VarName("token"), VarDefaultValue("default-s3cr3t"), VarSensitive())
+	app.genericVar(&pin, VarName("pin"), VarSensitive())
+
+	out, err := runCmd(t, app, "--help")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "default-s3cr3t") {
+		t.Errorf("expected default to be redacted in:\n%s", out)
+	}
+	// Zero values are not hidden
+	if app.Cmd.Flags().Lookup("pin").DefValue != "0" {
+		t.Error("expected zero value default to be kept")
+	}
+}
+
+func TestApp_SensitiveFlagError(t *testing.T) {
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}})
+	var pin int
+	app.genericVar(&pin, VarName("pin"), VarSensitive())
+	app.redactFlagErrors()
+
+	out, err := runCmd(t, app, "--pin=s3cr3t")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error()+out, "s3cr3t") {
+		t.Errorf("expected value to be redacted from '%v' and:\n%s", err, out)
+	}
+}
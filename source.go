@@ -0,0 +1,75 @@
+package ezcli
+
+import (
+	"os"
+	"strings"
+)
+
+// SourceKind is where a variable's value was resolved from
+type SourceKind string
+
+const (
+	SourceDefault  SourceKind = "default"
+	SourceFlag     SourceKind = "flag"
+	SourceEnv      SourceKind = "env"
+	SourceFile     SourceKind = "file"
+	SourceProvider SourceKind = "provider"
+)
+
+// Source is where a variable's value was resolved from
+type Source struct {
+	Kind   SourceKind
+	Detail string // Flag name, environment variable name, config file path or provider name
+}
+
+func (s Source) String() string {
+	if s.Detail == "" {
+		return string(s.Kind)
+	}
+	return string(s.Kind) + " " + s.Detail
+}
+
+// Source is where the named variable's value was resolved from when the App last loaded
+// The Kind is empty if there is no variable with the name
+func (a *App) Source(name string) Source {
+	return a.sources[name]
+}
+
+// IsSet is true if the named variable was set by a flag, environment variable, config file or provider
+func (a *App) IsSet(name string) bool {
+	kind := a.Source(name).Kind
+	return kind != "" && kind != SourceDefault
+}
+
+// resolveSources records where every variable's value comes from
+// This follows Viper's order of flag, environment variable, config then default
+func (a *App) resolveSources() {
+	for _, v := range a.vars {
+		a.sources[v.opts.Name] = a.sourceOf(v)
+	}
+}
+
+func (a *App) sourceOf(v *variable) Source {
+	key := a.key(v.opts)
+	if v.flag != nil && v.flag.Changed {
+		return Source{Kind: SourceFlag, Detail: "--" + v.flag.Name}
+	}
+	// Viper ignores empty environment variables
+	if env := a.env(v.opts); env != "" {
+		if os.Getenv(env) != "" {
+			return Source{Kind: SourceEnv, Detail: env}
+		}
+		if v.opts.Sensitive && os.Getenv(env+secretFileEnv) != "" {
+			return Source{Kind: SourceEnv, Detail: env + secretFileEnv}
+		}
+	}
+	if root := a.root(); root.config != nil {
+		if source, ok := root.config.sources[strings.ToLower(key)]; ok {
+			return source
+		}
+	}
+	if a.Viper.InConfig(key) {
+		return Source{Kind: SourceFile, Detail: a.Viper.ConfigFileUsed()}
+	}
+	return Source{Kind: SourceDefault}
+}
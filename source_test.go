@@ -0,0 +1,85 @@
+package ezcli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func sourceApp(t *testing.T) (*App, string) {
+	t.Helper()
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "file: from-file\nenv: from-file\nflag: from-file\n")
+	t.Setenv("TOOL_ENV", "from-env")
+	t.Setenv("TOOL_FLAG", "from-env")
+
+	app := New(&cobra.Command{Use: "tool", Run: func(cmd *cobra.Command, args []string) {}})
+	var def, file, env, flag string
+	app.genericVar(&def, VarName("default"), VarDefaultValue("from-default"))
+	app.genericVar(&file, VarName("file"))
+	app.genericVar(&env, VarName("env"), VarEnv("TOOL_ENV"))
+	app.genericVar(&flag, VarName("flag"), VarEnv("TOOL_FLAG"))
+	app.Init(path, "")
+	return app, path
+}
+
+func TestApp_Source(t *testing.T) {
+	app, path := sourceApp(t)
+	_, err := runCmd(t, app, "--flag=from-flag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source Source
+		isSet  bool
+	}{
+		{"default", Source{Kind: SourceDefault}, false},
+		{"file", Source{Kind: SourceFile, Detail: path}, true},
+		{"env", Source{Kind: SourceEnv, Detail: "TOOL_ENV"}, true},
+		{"flag", Source{Kind: SourceFlag, Detail: "--flag"}, true},
+		{"unknown", Source{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := app.Source(test.name); got != test.source {
+				t.Errorf("expected source '%s' got '%s'", test.source, got)
+			}
+			if got := app.IsSet(test.name); got != test.isSet {
+				t.Errorf("expected IsSet %t got %t", test.isSet, got)
+			}
+		})
+	}
+}
+
+func TestApp_ConfigShow(t *testing.T) {
+	app, path := sourceApp(t)
+	var childVal int
+	child := app.Child(New(&cobra.Command{Use: "child"}))
+	child.IntVar(&childVal, "value", 1337, "usage")
+	app.AddConfigCommands()
+
+	out, err := runCmd(t, app, "config", "show")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{
+		{"default", "from-default", "default"},
+		{"file", "from-file", "file " + path},
+		{"env", "from-env", "env TOOL_ENV"},
+		{"flag", "from-env", "env TOOL_FLAG"},
+		{"child.value", "1337", "default"},
+	} {
+		found := false
+		for _, line := range strings.Split(out, "\n") {
+			if strings.Join(strings.Fields(line), " ") == strings.Join(row, " ") {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected row %v in:\n%s", row, out)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package ezcli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type unknownKeyMode int
+
+const (
+	unknownKeysIgnore unknownKeyMode = iota
+	unknownKeysWarn
+	unknownKeysError
+)
+
+// unknownKey is a config key that doesn't belong to any variable
+type unknownKey struct {
+	key    string
+	source Source
+}
+
+func (u unknownKey) String(suggestion string) string {
+	msg := fmt.Sprintf("unknown config key %q in %s", u.key, u.source.Detail)
+	if suggestion != "" {
+		msg += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return msg
+}
+
+// knownKeys are the lower cased config keys of every variable in the App tree
+func (a *App) knownKeys() map[string]string {
+	keys := make(map[string]string)
+	a.visit(func(app *App) {
+		for _, v := range app.vars {
+			key := app.key(v.opts)
+			keys[strings.ToLower(key)] = key
+		}
+	})
+	return keys
+}
+
+// findUnknownKeys returns every key in src that isn't a variable or nested within one
+func (a *App) findUnknownKeys(src map[string]any, source Source) []unknownKey {
+	unknown := make([]unknownKey, 0)
+	if a.opts.unknownKeys == unknownKeysIgnore {
+		return unknown
+	}
+	known := a.knownKeys()
+	for _, key := range flattenKeys(src, "") {
+		// Profiles hold the same keys as the rest of the config
+		if inProfile, ok := profileKey(key); ok && a.opts.profiles {
+			if inProfile == extendsKey || isKnownKey(inProfile, known) {
+				continue
+			}
+		}
+		if !isKnownKey(key, known) {
+			unknown = append(unknown, unknownKey{key: key, source: source})
+		}
+	}
+	return unknown
+}
+
+func isKnownKey(key string, known map[string]string) bool {
+	for {
+		if _, ok := known[key]; ok {
+			return true
+		}
+		// Values such as maps are nested below their variable's key
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// checkUnknownKeys fails or warns about unknown keys depending on the App's mode
+func (a *App) checkUnknownKeys(unknown []unknownKey) error {
+	if len(unknown) == 0 || a.opts.unknownKeys == unknownKeysIgnore {
+		return nil
+	}
+
+	known := a.knownKeys()
+	msgs := make([]string, len(unknown))
+	for i, u := range unknown {
+		suggestion := suggestKey(u.key, known)
+		if inProfile, ok := profileKey(u.key); ok && a.opts.profiles {
+			if suggestion = suggestKey(inProfile, known); suggestion != "" {
+				suggestion = strings.TrimSuffix(u.key, inProfile) + suggestion
+			}
+		}
+		msgs[i] = u.String(suggestion)
+	}
+
+	if a.opts.unknownKeys == unknownKeysError {
+		return errors.New(strings.Join(msgs, "\n"))
+	}
+	for _, msg := range msgs {
+		// TODO use log
+		fmt.Fprintln(a.Cmd.ErrOrStderr(), "Warning:", msg)
+	}
+	return nil
+}
+
+// suggestKey finds the closest known key to a misspelt one, if any are close enough
+func suggestKey(key string, known map[string]string) string {
+	best, bestDistance := "", len(key)/3+2
+	for lower, original := range known {
+		distance := levenshtein(key, lower)
+		if distance < bestDistance || (distance == bestDistance && best != "" && original < best) {
+			best, bestDistance = original, distance
+		}
+	}
+	return best
+}
+
+// levenshtein is the number of single character edits to turn a into b
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
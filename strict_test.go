@@ -0,0 +1,81 @@
+package ezcli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func strictApp(t *testing.T, optFn appOptFn) (*App, *strings.Builder, string) {
+	t.Helper()
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), `
+timout: 5s
+server:
+  host: example.com
+foo:
+  name: child
+  nmae: typo
+`)
+	app := New(&cobra.Command{Use: "tool"}, optFn)
+	out := &strings.Builder{}
+	app.Cmd.SetErr(out)
+	var timeout time.Duration
+	var host, name string
+	app.DurationVar(&timeout, "timeout", 0, "usage")
+	app.StringVar(&host, "server.host", "", "usage")
+	child := app.Child(New(&cobra.Command{Use: "foo"}))
+	child.StringVar(&name, "name", "", "usage")
+	app.Init(path, "")
+	return app, out, path
+}
+
+func TestApp_StrictConfig(t *testing.T) {
+	app, _, path := strictApp(t, AppStrictConfig())
+	err := app.load()
+	if err == nil {
+		t.Fatal("expected an error for unknown keys")
+	}
+	for _, msg := range []string{
+		`unknown config key "timout" in ` + path + `, did you mean "timeout"?`,
+		`unknown config key "foo.nmae" in ` + path + `, did you mean "foo.name"?`,
+	} {
+		if !strings.Contains(err.Error(), msg) {
+			t.Errorf("expected '%s' in:\n%v", msg, err)
+		}
+	}
+	if strings.Contains(err.Error(), "server.host") || strings.Contains(err.Error(), `"foo.name" in`) {
+		t.Errorf("expected known keys to be accepted:\n%v", err)
+	}
+}
+
+func TestApp_LenientConfig(t *testing.T) {
+	app, out, path := strictApp(t, AppLenientConfig())
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := `Warning: unknown config key "timout" in ` + path + `, did you mean "timeout"?`
+	if !strings.Contains(out.String(), msg) {
+		t.Errorf("expected '%s' in:\n%s", msg, out)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	known := map[string]string{"timeout": "timeout", "server.host": "server.host", "name": "Name"}
+	tests := []struct {
+		key, expected string
+	}{
+		{"timout", "timeout"},
+		{"server.hots", "server.host"},
+		{"nmae", "Name"},
+		{"completely.different", ""},
+	}
+	for _, test := range tests {
+		if got := suggestKey(test.key, known); got != test.expected {
+			t.Errorf("expected '%s' for '%s' got '%s'", test.expected, test.key, got)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package ezcli
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
 const (
-	tagEnv  = "env"
-	tagFlag = "flag"
+	tagEnv     = "env"
+	tagFlag    = "flag"
+	tagSecret  = "secret"
+	tagPort    = "port"
+	tagSchemes = "schemes"
+	tagLayout  = "layout"
 )
 
 func (a *App) parseTags(field reflect.StructField) []varOptFn {
@@ -24,12 +30,33 @@ func (a *App) parseTags(field reflect.StructField) []varOptFn {
 
 	envVal, exists := field.Tag.Lookup(tagEnv)
 	if exists {
-		// Use the same name as the flag if no custom name provided
-		// Will automatically uppercase from the flag
+		// Without a custom name use the App's prefixed name
+		// or the upper cased flag when there is no prefix
 		if envVal == "" {
-			envVal = strings.ToUpper(flagVal)
+			varOptFns = append(varOptFns, varEnvFromName())
+		} else {
+			varOptFns = append(varOptFns, VarEnv(envVal))
 		}
-		varOptFns = append(varOptFns, VarEnv(envVal))
+	}
+
+	if _, exists := field.Tag.Lookup(tagSecret); exists {
+		varOptFns = append(varOptFns, VarSensitive())
+	}
+
+	if portVal, exists := field.Tag.Lookup(tagPort); exists {
+		port, err := strconv.ParseUint(portVal, 10, 16)
+		if err != nil {
+			panic(fmt.Sprintf("invalid port tag %q on %s", portVal, field.Name))
+		}
+		varOptFns = append(varOptFns, VarDefaultPort(uint16(port)))
+	}
+
+	if schemesVal, exists := field.Tag.Lookup(tagSchemes); exists {
+		varOptFns = append(varOptFns, VarURLSchemes(strings.Split(schemesVal, ",")...))
+	}
+
+	if layoutVal, exists := field.Tag.Lookup(tagLayout); exists {
+		varOptFns = append(varOptFns, VarTimeLayout(layoutVal))
 	}
 
 	return varOptFns
@@ -57,6 +84,12 @@ func (a *App) StructVar(s any) {
 			continue
 		}
 
+		// Types that parse themselves, before structs and kinds they may be built on
+		if isCustomType(fType.Type) || valueTypes[fType.Type] {
+			setValue(a, fVal, append(a.parseTags(fType), VarDefaultValue(fVal.Interface())))
+			continue
+		}
+
 		// Recurse over structs
 		if fType.Type.Kind() == reflect.Struct {
 			a.StructVar(fVal)
@@ -69,13 +102,17 @@ func (a *App) StructVar(s any) {
 		// Use our structs set value as the default
 		optFns = append(optFns, VarDefaultValue(fVal.Interface()))
 
+		// Named types, eg: time.Duration or type Port uint16, keep their own type
+		if fType.Type.PkgPath() != "" {
+			setValue(a, fVal, optFns)
+			continue
+		}
+
 		switch fType.Type.Kind() {
 		case reflect.Bool:
 			v := fVal.Bool()
-			a.genericVar(&v, optFns...)
-			a.postLoadFuncs = append(a.postLoadFuncs, func() {
-				fVal.SetBool(v)
-			})
+			opts := a.genericVar(&v, optFns...)
+			a.onLoad(opts, setter(func() { fVal.SetBool(v) }))
 
 		// Handle ints
 		case reflect.Int:
@@ -101,12 +138,19 @@ func (a *App) StructVar(s any) {
 		case reflect.Uint64:
 			setUint[uint64](a, fVal, optFns)
 
+		// Handle floats
+		case reflect.Float32:
+			setFloat[float32](a, fVal, optFns)
+		case reflect.Float64:
+			setFloat[float64](a, fVal, optFns)
+
 		case reflect.String:
 			v := fVal.String()
-			a.genericVar(&v, optFns...)
-			a.postLoadFuncs = append(a.postLoadFuncs, func() {
-				fVal.SetString(v)
-			})
+			opts := a.genericVar(&v, optFns...)
+			a.onLoad(opts, setter(func() { fVal.SetString(v) }))
+
+		case reflect.Slice, reflect.Map:
+			setValue(a, fVal, optFns)
 		default:
 			panic("unable to use struct value")
 			// Do we skip struct values we can't use?
@@ -118,16 +162,26 @@ func (a *App) StructVar(s any) {
 
 func setUint[T uint | uint8 | uint16 | uint32 | uint64](a *App, val reflect.Value, optFns []varOptFn) {
 	v := T(val.Uint())
-	a.genericVar(&v, optFns...)
-	a.postLoadFuncs = append(a.postLoadFuncs, func() {
-		val.SetUint(uint64(v))
-	})
+	opts := a.genericVar(&v, optFns...)
+	a.onLoad(opts, setter(func() { val.SetUint(uint64(v)) }))
 }
 
 func setInt[T int | int8 | int16 | int32 | int64](a *App, val reflect.Value, optFns []varOptFn) {
 	v := T(val.Int())
-	a.genericVar(&v, optFns...)
-	a.postLoadFuncs = append(a.postLoadFuncs, func() {
-		val.SetInt(int64(v))
-	})
+	opts := a.genericVar(&v, optFns...)
+	a.onLoad(opts, setter(func() { val.SetInt(int64(v)) }))
+}
+
+func setFloat[T float32 | float64](a *App, val reflect.Value, optFns []varOptFn) {
+	v := T(val.Float())
+	opts := a.genericVar(&v, optFns...)
+	a.onLoad(opts, setter(func() { val.SetFloat(float64(v)) }))
+}
+
+// setValue registers a variable of the field's own type, for types genericVar supports directly
+func setValue(a *App, val reflect.Value, optFns []varOptFn) {
+	v := reflect.New(val.Type())
+	v.Elem().Set(val)
+	opts := a.genericVar(v.Interface(), optFns...)
+	a.onLoad(opts, setter(func() { val.Set(v.Elem()) }))
 }
@@ -1,8 +1,15 @@
 package ezcli
 
 import (
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -10,10 +17,15 @@ import (
 func TestApp_StructVar(t *testing.T) {
 	type TestStruct struct {
 		unexported     string
-		String         string `flag:"custom" env:""`
-		Bool           bool   `flag:""`
-		Int            int    `env:""`
-		UInt           uint   `env:""`
+		String         string  `flag:"custom" env:""`
+		Bool           bool    `flag:""`
+		Int            int     `env:""`
+		UInt           uint    `env:""`
+		Ratio          float64 `env:""`
+		Threshold      float32
+		Ports          []int `env:""`
+		Servers        []net.IP
+		Labels         map[string]string `env:""`
 		InnerNoPointer struct {
 			InnerStr string
 		}
@@ -32,6 +44,9 @@ func TestApp_StructVar(t *testing.T) {
 		t.Error(err)
 		return
 	}
+	t.Setenv("RATIO", "2.5e-1")
+	t.Setenv("PORTS", "80 443")
+	t.Setenv("LABELS", "env=prod,team=core")
 
 	app := New(&cobra.Command{
 		Use:   "cmd",
@@ -46,9 +61,16 @@ func TestApp_StructVar(t *testing.T) {
 		// Should these be automatically lower cased?
 		"--Bool=false",
 		"--custom=teststring",
+		"--Threshold=0.75",
+		"--Servers=1.1.1.1,8.8.8.8",
 	})
 
-	app.initConfig(t.TempDir(), "doesntexist")()
+	app.Init(filepath.Join(t.TempDir(), "doesntexist.json"), "doesntexist")
+	err = app.load()
+	if err != nil {
+		t.Error(err)
+		return
+	}
 
 	if s.unexported != "untouched" {
 		t.Error("we touched an unexported field")
@@ -65,4 +87,132 @@ func TestApp_StructVar(t *testing.T) {
 	if s.String != "teststring" {
 		t.Errorf("expected '%s' got '%s'\n", "teststring", s.String)
 	}
+	if s.Ratio != 0.25 {
+		t.Errorf("expected RATIO value from environment '%v' got '%v'\n", 0.25, s.Ratio)
+	}
+	if s.Threshold != 0.75 {
+		t.Errorf("expected '%v' got '%v'\n", 0.75, s.Threshold)
+	}
+	if !reflect.DeepEqual(s.Ports, []int{80, 443}) {
+		t.Errorf("expected PORTS value from environment '%v' got '%v'\n", []int{80, 443}, s.Ports)
+	}
+	if !reflect.DeepEqual(s.Labels, map[string]string{"env": "prod", "team": "core"}) {
+		t.Errorf("expected LABELS value from environment got '%v'\n", s.Labels)
+	}
+	if !reflect.DeepEqual(s.Servers, []net.IP{net.IPv4(1, 1, 1, 1), net.IPv4(8, 8, 8, 8)}) {
+		t.Errorf("expected '%v' got '%v'\n", "[1.1.1.1 8.8.8.8]", s.Servers)
+	}
+}
+
+func TestApp_StructVarEnvPrefix(t *testing.T) {
+	s := &struct {
+		Port int    `env:""`
+		Host string `env:"TEST_HOST"`
+	}{}
+	t.Setenv("MYTOOL_PORT", "8080")
+	t.Setenv("PORT", "9090")
+	t.Setenv("TEST_HOST", "example.com")
+
+	app := New(&cobra.Command{Use: "tool"}, AppEnvPrefix("MYTOOL"))
+	app.StructVar(s)
+	err := app.InitNoConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 8080 || s.Host != "example.com" {
+		t.Errorf("expected '8080' 'example.com' got '%d' '%s'", s.Port, s.Host)
+	}
+}
+
+func TestApp_StructVarSecret(t *testing.T) {
+	s := &struct {
+		Token string `env:"" secret:""`
+	}{}
+	app := subject()
+	app.StructVar(s)
+
+	for _, v := range app.vars {
+		if !v.opts.Sensitive {
+			t.Errorf("expected '%s' to be sensitive", v.opts.Name)
+		}
+	}
+}
+
+func TestApp_StructVarURLAndTime(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, filepath.Join(dir, "tool.yaml"), strings.Join([]string{
+		"endpoint: https://api.example.com/v1",
+		"since: 2024-03-01",
+		"zone: Europe/London",
+		`match: "^svc-[a-z]+$"`,
+		"",
+	}, "\n"))
+
+	s := &struct {
+		Endpoint *url.URL  `schemes:"http,https"`
+		Since    time.Time `layout:"2006-01-02"`
+		Until    time.Time
+		Zone     *time.Location
+		Match    *regexp.Regexp
+	}{
+		Zone: time.UTC,
+	}
+	app := New(&cobra.Command{Use: "tool"})
+	app.StructVar(s)
+	app.Init(path, "")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Endpoint.Host != "api.example.com" {
+		t.Errorf("expected 'api.example.com' got '%s'", s.Endpoint.Host)
+	}
+	if !s.Since.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected '2024-03-01' got '%s'", s.Since)
+	}
+	if !s.Until.IsZero() {
+		t.Errorf("expected the zero time got '%s'", s.Until)
+	}
+	if s.Zone.String() != "Europe/London" {
+		t.Errorf("expected 'Europe/London' got '%s'", s.Zone)
+	}
+	if !s.Match.MatchString("svc-api") || s.Match.MatchString("svc-1") {
+		t.Errorf("expected '^svc-[a-z]+$' got '%s'", s.Match)
+	}
+}
+
+func TestApp_URLAndTimeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{"scheme", "endpoint: ftp://example.com\n", `invalid value for endpoint: invalid URL "ftp://example.com", expected a scheme of http, https`},
+		{"relative", "endpoint: /v1\n", `invalid value for endpoint: invalid URL "/v1", expected an absolute URL`},
+		{"layout", "since: 01/03/2024\n", `invalid value for since: invalid time "01/03/2024", expected the layout 2006-01-02`},
+		{"zone", "zone: Mars/Olympus\n", `invalid value for zone: invalid time zone "Mars/Olympus"`},
+		{"regexp", "match: \"a(\"\n", `invalid value for match: invalid regular expression "a("`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), test.config)
+			s := &struct {
+				Endpoint *url.URL       `flag:"endpoint" schemes:"http,https"`
+				Since    time.Time      `flag:"since" layout:"2006-01-02"`
+				Zone     *time.Location `flag:"zone"`
+				Match    *regexp.Regexp `flag:"match"`
+			}{}
+			app := New(&cobra.Command{Use: "tool"})
+			app.StructVar(s)
+			app.Init(path, "")
+
+			// Values are parsed when the App loads, before any command runs
+			err := app.load()
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
 }
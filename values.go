@@ -0,0 +1,462 @@
+package ezcli
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// sliceValue is a pflag.Value for a comma separated list, the flag can also be repeated
+// Unlike pflag's own slices the value is printed without losing precision
+type sliceValue[T any] struct {
+	value   *[]T
+	typ     string
+	parse   func(string) (T, error)
+	format  func(T) string
+	changed bool
+}
+
+func newSliceValue[T any](value *[]T, defaultValue []T, typ string, parse func(string) (T, error), format func(T) string) *sliceValue[T] {
+	*value = append([]T(nil), defaultValue...)
+	return &sliceValue[T]{value: value, typ: typ, parse: parse, format: format}
+}
+
+func (s *sliceValue[T]) Set(val string) error {
+	parsed, err := parseSlice(val, s.parse)
+	if err != nil {
+		return err
+	}
+	// The first value replaces the default, later ones are appended
+	if !s.changed {
+		*s.value = parsed
+	} else {
+		*s.value = append(*s.value, parsed...)
+	}
+	s.changed = true
+	return nil
+}
+
+func (s *sliceValue[T]) Type() string {
+	return s.typ
+}
+
+func (s *sliceValue[T]) String() string {
+	if s.value == nil {
+		return "[]"
+	}
+	items := make([]string, len(*s.value))
+	for i, item := range *s.value {
+		items[i] = s.format(item)
+	}
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+// loadSlice sets a slice variable from its resolved value, parsing each item
+func loadSlice[T any](a *App, opts *VarOpts, val reflect.Value, parse func(string) (T, error)) func() (func(), error) {
+	return func() (func(), error) {
+		items, err := parseSlice(a.Viper.Get(a.key(opts)), parse)
+		if err != nil {
+			return nil, a.redactErr(opts, errors.Wrapf(err, "invalid value for %s", opts.Name))
+		}
+		return func() { val.Set(reflect.ValueOf(items)) }, nil
+	}
+}
+
+// scalarValue is a pflag.Value for a single value parsed and formatted by functions
+type scalarValue[T any] struct {
+	value  *T
+	typ    string
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+func newScalarValue[T any](value *T, defaultValue T, typ string, parse func(string) (T, error), format func(T) string) *scalarValue[T] {
+	*value = defaultValue
+	return &scalarValue[T]{value: value, typ: typ, parse: parse, format: format}
+}
+
+func (s *scalarValue[T]) Set(val string) error {
+	parsed, err := s.parse(val)
+	if err != nil {
+		return err
+	}
+	*s.value = parsed
+	return nil
+}
+
+func (s *scalarValue[T]) Type() string {
+	return s.typ
+}
+
+func (s *scalarValue[T]) String() string {
+	if s.value == nil {
+		return ""
+	}
+	return s.format(*s.value)
+}
+
+// loadScalar sets a variable from its resolved value, an empty value is the zero value
+func loadScalar[T any](a *App, opts *VarOpts, val reflect.Value, parse func(string) (T, error)) func() (func(), error) {
+	return func() (func(), error) {
+		var parsed T
+		raw := a.Viper.Get(a.key(opts))
+		s, ok := raw.(string)
+		if !ok && raw != nil {
+			s = formatItem(raw)
+		}
+		if s != "" {
+			var err error
+			parsed, err = parse(s)
+			if err != nil {
+				return nil, a.redactErr(opts, errors.Wrapf(err, "invalid value for %s", opts.Name))
+			}
+		}
+		return func() { val.Set(reflect.ValueOf(parsed)) }, nil
+	}
+}
+
+// mapValue is a pflag.Value for comma separated key=value pairs, repeated flags are merged
+type mapValue[T any] struct {
+	value   *map[string]T
+	typ     string
+	parse   func(string) (T, error)
+	format  func(T) string
+	changed bool
+}
+
+func newMapValue[T any](value *map[string]T, defaultValue map[string]T, typ string, parse func(string) (T, error), format func(T) string) *mapValue[T] {
+	*value = make(map[string]T, len(defaultValue))
+	for k, v := range defaultValue {
+		(*value)[k] = v
+	}
+	return &mapValue[T]{value: value, typ: typ, parse: parse, format: format}
+}
+
+func (m *mapValue[T]) Set(val string) error {
+	parsed, err := parseMap(val, m.parse)
+	if err != nil {
+		return err
+	}
+	// The first value replaces the default, later ones are merged
+	if !m.changed {
+		*m.value = parsed
+	} else {
+		for k, v := range parsed {
+			(*m.value)[k] = v
+		}
+	}
+	m.changed = true
+	return nil
+}
+
+func (m *mapValue[T]) Type() string {
+	return m.typ
+}
+
+func (m *mapValue[T]) String() string {
+	if m.value == nil {
+		return "[]"
+	}
+	items := make([]string, 0, len(*m.value))
+	for k, v := range *m.value {
+		items = append(items, k+"="+m.format(v))
+	}
+	sort.Strings(items)
+	return "[" + strings.Join(items, ",") + "]"
+}
+
+// loadMap sets a map variable from its resolved value, parsing each value
+func loadMap[T any](a *App, opts *VarOpts, val reflect.Value, parse func(string) (T, error)) func() (func(), error) {
+	return func() (func(), error) {
+		items, err := parseMap(a.Viper.Get(a.key(opts)), parse)
+		if err != nil {
+			return nil, a.redactErr(opts, errors.Wrapf(err, "invalid value for %s", opts.Name))
+		}
+		return func() { val.Set(reflect.ValueOf(items)) }, nil
+	}
+}
+
+// customValue returns a pflag.Value for a pointer to a pflag.Value, or to an encoding.TextUnmarshaler
+// that can also be printed as an encoding.TextMarshaler or fmt.Stringer
+func customValue(v any) (pflag.Value, bool) {
+	switch value := v.(type) {
+	case pflag.Value:
+		return value, true
+	case encoding.TextUnmarshaler:
+		switch v.(type) {
+		case encoding.TextMarshaler, fmt.Stringer:
+			return &textValue{value}, true
+		}
+	}
+	return nil, false
+}
+
+// isCustomType is true when a pointer to t can be used as a customValue
+func isCustomType(t reflect.Type) bool {
+	_, ok := customValue(reflect.New(t).Interface())
+	return ok
+}
+
+// textValue is a pflag.Value for an encoding.TextUnmarshaler
+type textValue struct {
+	value encoding.TextUnmarshaler
+}
+
+func (t *textValue) Set(s string) error {
+	return t.value.UnmarshalText([]byte(s))
+}
+
+func (t *textValue) Type() string {
+	return reflect.TypeOf(t.value).Elem().Name()
+}
+
+func (t *textValue) String() string {
+	if marshaler, ok := t.value.(encoding.TextMarshaler); ok {
+		b, err := marshaler.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+	return t.value.(fmt.Stringer).String()
+}
+
+// typedValue returns a pflag.Value for a pointer to a custom type or a named type over a basic kind
+func typedValue(v any) (pflag.Value, bool) {
+	if value, ok := customValue(v); ok {
+		return value, true
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || !isKindType(rv.Type().Elem()) {
+		return nil, false
+	}
+	return &kindValue{value: rv.Elem()}, true
+}
+
+// loadValue sets a custom or named type variable by parsing its resolved value into a new value of its type
+// Flags have already parsed their value, unset variables go back to their default
+func loadValue(a *App, opts *VarOpts, val reflect.Value, flagSet *pflag.FlagSet) func() (func(), error) {
+	return func() (func(), error) {
+		if flagSet.Lookup(opts.Name).Changed {
+			return func() {}, nil
+		}
+		key := a.key(opts)
+		if !a.Viper.IsSet(key) {
+			return func() { val.Set(reflect.ValueOf(opts.DefaultValue)) }, nil
+		}
+
+		raw := a.Viper.Get(key)
+		fresh := reflect.New(val.Type())
+		value, _ := typedValue(fresh.Interface())
+		var err error
+		if kind, ok := value.(*kindValue); ok {
+			// Config lists and objects are parsed as they are
+			err = kind.setAny(raw)
+		} else {
+			s, ok := raw.(string)
+			if !ok {
+				s = formatItem(raw)
+			}
+			err = value.Set(s)
+		}
+		if err != nil {
+			return nil, a.redactErr(opts, errors.Wrapf(err, "invalid value for %s", opts.Name))
+		}
+		return func() { val.Set(fresh.Elem()) }, nil
+	}
+}
+
+// customText is the text form of a custom value, ok is false for every other type
+func customText(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !isCustomType(rv.Type()) {
+		return "", false
+	}
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	value, _ := customValue(ptr.Interface())
+	return value.String(), true
+}
+
+// isKindType is true for types built on a basic kind, or slices and string keyed maps of them
+// eg: type Port uint16, type Modes []Mode or map[string]Port
+func isKindType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return isKindType(t.Elem())
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && isKindType(t.Elem())
+	}
+	return false
+}
+
+// kindValue is a pflag.Value for named types, values are parsed by their kind and converted
+// Help shows the type's own name
+type kindValue struct {
+	value   reflect.Value
+	changed bool
+}
+
+func (k *kindValue) Set(s string) error {
+	parsed, err := parseKind(k.value.Type(), s)
+	if err != nil {
+		return err
+	}
+	// Like other slices and maps the first value replaces the default, later ones are added
+	switch {
+	case !k.changed:
+		k.value.Set(parsed)
+	case parsed.Kind() == reflect.Slice:
+		k.value.Set(reflect.AppendSlice(k.value, parsed))
+	case parsed.Kind() == reflect.Map:
+		iter := parsed.MapRange()
+		for iter.Next() {
+			k.value.SetMapIndex(iter.Key(), iter.Value())
+		}
+	default:
+		k.value.Set(parsed)
+	}
+	k.changed = true
+	return nil
+}
+
+// setAny sets the value from a config value, which may already be a list or object
+func (k *kindValue) setAny(raw any) error {
+	parsed, err := parseKind(k.value.Type(), raw)
+	if err != nil {
+		return err
+	}
+	k.value.Set(parsed)
+	return nil
+}
+
+func (k *kindValue) Type() string {
+	return typeName(k.value.Type())
+}
+
+func (k *kindValue) String() string {
+	return formatKind(k.value)
+}
+
+// typeName is the name of a type without its package, eg: Port, []Port or map[string]Port
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	}
+	return t.String()
+}
+
+// parseKind parses a string, or a config list or object, into a value of type t
+func parseKind(t reflect.Type, raw any) (reflect.Value, error) {
+	parseElem := func(s string) (reflect.Value, error) {
+		return parseKind(t.Elem(), s)
+	}
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Slice:
+		items, err := parseSlice(raw, parseElem)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.MakeSlice(t, len(items), len(items)))
+		for i, item := range items {
+			v.Index(i).Set(item)
+		}
+		return v, nil
+	case reflect.Map:
+		items, err := parseMap(raw, parseElem)
+		if err != nil {
+			return v, err
+		}
+		v.Set(reflect.MakeMapWithSize(t, len(items)))
+		for key, item := range items {
+			v.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), item)
+		}
+		return v, nil
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		s = formatItem(raw)
+	}
+	var err error
+	switch t.Kind() {
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(s)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if t == durationType {
+			var d time.Duration
+			d, err = time.ParseDuration(s)
+			i = int64(d)
+		} else {
+			i, err = strconv.ParseInt(s, 0, t.Bits())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(s, 0, t.Bits())
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(s, t.Bits())
+		v.SetFloat(f)
+	case reflect.String:
+		v.SetString(s)
+	default:
+		err = errors.Errorf("unable to parse %s", t)
+	}
+	return v, err
+}
+
+// formatKind formats a value by its kind, so it can be parsed again by parseKind
+func formatKind(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = formatKind(v.Index(i))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			items = append(items, iter.Key().String()+"="+formatKind(iter.Value()))
+		}
+		sort.Strings(items)
+		return "[" + strings.Join(items, ",") + "]"
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			return time.Duration(v.Int()).String()
+		}
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+	}
+	return v.String()
+}
@@ -0,0 +1,326 @@
+package ezcli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// logLevel is an encoding.TextUnmarshaler and encoding.TextMarshaler over an int
+type logLevel int
+
+const (
+	levelInfo logLevel = iota
+	levelDebug
+	levelError
+)
+
+var levelNames = []string{"info", "debug", "error"}
+
+func (l logLevel) MarshalText() ([]byte, error) {
+	return []byte(levelNames[l]), nil
+}
+
+func (l *logLevel) UnmarshalText(b []byte) error {
+	for i, name := range levelNames {
+		if strings.EqualFold(name, string(b)) {
+			*l = logLevel(i)
+			return nil
+		}
+	}
+	return errors.Errorf("unknown log level %q", b)
+}
+
+// region is a pflag.Value over a struct
+type region struct {
+	name string
+}
+
+func (r *region) Set(s string) error {
+	if s != "eu-west-1" && s != "us-east-1" {
+		return errors.Errorf("unknown region %q", s)
+	}
+	r.name = s
+	return nil
+}
+
+func (r *region) String() string {
+	return r.name
+}
+
+func (r *region) Type() string {
+	return "region"
+}
+
+// version is an encoding.TextUnmarshaler and fmt.Stringer
+type version struct {
+	major, minor int
+}
+
+func (v *version) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "v%d.%d", &v.major, &v.minor)
+	return errors.Wrapf(err, "invalid version %q", b)
+}
+
+func (v version) String() string {
+	return fmt.Sprintf("v%d.%d", v.major, v.minor)
+}
+
+func TestApp_CustomTypes(t *testing.T) {
+	doGVarFlagTest[logLevel](t, "debug", levelDebug)
+	doGVarFlagTest[region](t, "eu-west-1", region{"eu-west-1"})
+	doGVarFlagTest[version](t, "v1.2", version{1, 2})
+
+	doGVarEnvTest[logLevel](t, "ERROR", levelError)
+	doGVarEnvTest[region](t, "us-east-1", region{"us-east-1"})
+	doGVarEnvTest[version](t, "v3.4", version{3, 4})
+
+	doGVarConfigTest[logLevel](t, "debug", levelDebug)
+	doGVarConfigTest[region](t, "eu-west-1", region{"eu-west-1"})
+	doGVarConfigTest[version](t, "v5.6", version{5, 6})
+
+	doGVarEnvAndFlagTest[logLevel](t, "debug", "error", levelDebug)
+	doGVarEnvAndFlagTest[region](t, "eu-west-1", "us-east-1", region{"eu-west-1"})
+	doGVarEnvAndFlagTest[version](t, "v1.2", "v3.4", version{1, 2})
+}
+
+func TestApp_CustomTypeDefault(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, filepath.Join(dir, "tool.yaml"), "level: error\n")
+	app := New(&cobra.Command{Use: "tool"})
+	level := levelInfo
+	app.Var(&level, "level", levelDebug, "log level")
+	if flag := app.Cmd.PersistentFlags().Lookup("level"); flag.DefValue != "debug" || flag.Value.Type() != "logLevel" {
+		t.Errorf("expected help to show 'logLevel' defaulting to 'debug' got '%s' '%s'", flag.Value.Type(), flag.DefValue)
+	}
+	app.Init(path, "")
+	app.AddConfigCommands()
+
+	// Unset until the config is read
+	app.InitNoConfig()
+	if level != levelDebug {
+		t.Errorf("expected default '%v' got '%v'", levelDebug, level)
+	}
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != levelError {
+		t.Errorf("expected '%v' got '%v'", levelError, level)
+	}
+
+	out, err := runCmd(t, app, "config", "init", filepath.Join(dir, "init.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "init.yaml"))
+	if err != nil {
+		t.Fatal(err, out)
+	}
+	if !strings.Contains(string(b), `level: "debug"`) {
+		t.Errorf("expected the default as text got '%s'", b)
+	}
+}
+
+func TestApp_CustomTypeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"flag", []string{"--region=mars"}, "", `unknown region "mars"`},
+		{"env", nil, "ap-south-1", `invalid value for region: unknown region "ap-south-1"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_REGION", test.env)
+			app := subject()
+			var r region
+			app.genericVar(&r, VarName("region"), VarEnv("TEST_REGION"))
+
+			err := app.Cmd.ParseFlags(test.args)
+			// Environment variables are parsed when the App loads
+			if err == nil {
+				err = app.InitNoConfig()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestApp_StructVarCustomTypes(t *testing.T) {
+	s := &struct {
+		Level   logLevel `env:"TEST_LEVEL"`
+		Region  region
+		Version version
+	}{
+		Level:   levelDebug,
+		Version: version{1, 0},
+	}
+	t.Setenv("TEST_LEVEL", "error")
+
+	app := New(&cobra.Command{Use: "tool"})
+	app.StructVar(s)
+	err := app.Cmd.ParseFlags([]string{"--Region=eu-west-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+
+	if s.Level != levelError {
+		t.Errorf("expected '%v' got '%v'", levelError, s.Level)
+	}
+	if s.Region.name != "eu-west-1" {
+		t.Errorf("expected 'eu-west-1' got '%s'", s.Region.name)
+	}
+	if s.Version != (version{1, 0}) {
+		t.Errorf("expected default 'v1.0' got '%s'", s.Version)
+	}
+}
+
+// Named types over basic kinds
+type (
+	port    uint16
+	mode    string
+	weight  float64
+	enabled bool
+	modes   []mode
+)
+
+func TestApp_NamedTypes(t *testing.T) {
+	doGVarFlagTest[port](t, "8080", port(8080))
+	doGVarFlagTest[mode](t, "fast", mode("fast"))
+	doGVarFlagTest[weight](t, "0.25", weight(0.25))
+	doGVarFlagTest[enabled](t, "true", enabled(true))
+	doGVarFlagTest[[]port](t, "80,443", []port{80, 443})
+	doGVarFlagTest[modes](t, "fast,slow", modes{"fast", "slow"})
+	doGVarFlagTest[map[string]port](t, "http=80,https=443", map[string]port{"http": 80, "https": 443})
+
+	doGVarEnvTest[port](t, "8080", port(8080))
+	doGVarEnvTest[mode](t, "fast", mode("fast"))
+	doGVarEnvTest[[]port](t, "80 443", []port{80, 443})
+
+	doGVarConfigTest[port](t, 8080, port(8080))
+	doGVarConfigTest[mode](t, "fast", mode("fast"))
+	doGVarConfigTest[weight](t, 0.25, weight(0.25))
+	doGVarConfigTest[[]port](t, []int{80, 443}, []port{80, 443})
+	doGVarConfigTest[modes](t, []string{"fast", "slow"}, modes{"fast", "slow"})
+	doGVarConfigTest[map[string]port](t, map[string]int{"http": 80}, map[string]port{"http": 80})
+
+	doGVarEnvAndFlagTest[port](t, "8080", "9090", port(8080))
+	doGVarEnvAndFlagTest[[]port](t, "80", "443", []port{80})
+}
+
+func TestApp_NamedTypeHelp(t *testing.T) {
+	app := New(&cobra.Command{Use: "tool"})
+	var p port
+	var ps []port
+	var m mode
+	app.Var(&p, "port", port(80), "")
+	app.Var(&ps, "ports", []port{80, 443}, "")
+	app.Var(&m, "mode", mode("fast"), "")
+
+	tests := []struct {
+		name, typ, def string
+	}{
+		{"port", "port", "80"},
+		{"ports", "[]port", "[80,443]"},
+		{"mode", "mode", "fast"},
+	}
+	for _, test := range tests {
+		flag := app.Cmd.PersistentFlags().Lookup(test.name)
+		if flag.Value.Type() != test.typ || flag.DefValue != test.def {
+			t.Errorf("expected '%s' '%s' got '%s' '%s'", test.typ, test.def, flag.Value.Type(), flag.DefValue)
+		}
+	}
+
+	// Repeated slice flags replace the default then append
+	err := app.Cmd.ParseFlags([]string{"--ports=8080", "--ports=8443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+	if fmt.Sprint(ps) != "[8080 8443]" {
+		t.Errorf("expected '[8080 8443]' got '%v'", ps)
+	}
+	if p != 80 || m != "fast" {
+		t.Errorf("expected defaults '80' 'fast' got '%v' '%v'", p, m)
+	}
+}
+
+func TestApp_NamedTypeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      string
+		expected string
+	}{
+		{"flag", []string{"--port=70000"}, "", `value out of range`},
+		{"env", nil, "http", `invalid value for port: strconv.ParseUint: parsing "http"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TEST_PORT", test.env)
+			app := subject()
+			var p port
+			app.genericVar(&p, VarName("port"), VarEnv("TEST_PORT"))
+
+			err := app.Cmd.ParseFlags(test.args)
+			if err == nil {
+				err = app.InitNoConfig()
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("expected '%s' got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestApp_StructVarNamedTypes(t *testing.T) {
+	s := &struct {
+		Port    port `env:"TEST_PORT"`
+		Mode    mode
+		Ports   []port
+		Timeout time.Duration
+	}{
+		Port:    80,
+		Mode:    "fast",
+		Timeout: time.Second,
+	}
+	t.Setenv("TEST_PORT", "8080")
+
+	app := New(&cobra.Command{Use: "tool"})
+	app.StructVar(s)
+	if typ := app.Cmd.PersistentFlags().Lookup("Port").Value.Type(); typ != "port" {
+		t.Errorf("expected 'port' got '%s'", typ)
+	}
+	err := app.Cmd.ParseFlags([]string{"--Ports=1,2", "--Timeout=1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.InitNoConfig()
+
+	if s.Port != 8080 {
+		t.Errorf("expected '8080' got '%v'", s.Port)
+	}
+	if s.Mode != "fast" {
+		t.Errorf("expected default 'fast' got '%v'", s.Mode)
+	}
+	if fmt.Sprint(s.Ports) != "[1 2]" {
+		t.Errorf("expected '[1 2]' got '%v'", s.Ports)
+	}
+	if s.Timeout != time.Minute {
+		t.Errorf("expected '1m0s' got '%v'", s.Timeout)
+	}
+}
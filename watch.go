@@ -0,0 +1,239 @@
+package ezcli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// ConfigChange is a variable whose value changed when the config was reloaded
+// Sensitive and encrypted values are redacted
+type ConfigChange struct {
+	Key string // Config key of the variable
+	Old any    // Value before the reload
+	New any    // Value after the reload
+}
+
+// WatchConfig reloads the config whenever a config file, conf.d fragment or ConfigWatcher provider changes until ctx is done
+// Only variables whose value changed are set again, onChange is then called with every change
+// A config that fails to load or holds an invalid value is reported and the current values are kept
+// Variables are set while the App is locked, readers in other goroutines should use RLock
+// Call WatchConfig once the App has loaded, eg: from a command's Run
+func (a *App) WatchConfig(ctx context.Context, onChange func(changes []ConfigChange)) error {
+	root := a.root()
+	if root.config == nil {
+		return errors.New("unable to watch config, Init was not called")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "unable to watch config")
+	}
+
+	// Watch directories rather than files so we see editors replacing a file
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	watch := func(dir string) error {
+		if dirs[dir] {
+			return nil
+		}
+		err := watcher.Add(dir)
+		// Optional layers may not have a directory yet
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "unable to watch config directory %s", dir)
+		}
+		dirs[dir] = true
+		return nil
+	}
+	// conf.d directories are watched for new fragments too
+	confDirs := make(map[string]bool)
+	paths := append([]string{}, root.config.files...)
+	for _, layer := range root.configLayers() {
+		path, err := homedir.Expand(layer.Path)
+		if err != nil {
+			watcher.Close()
+			return errors.Wrapf(err, "unable to expand config path %s", layer.Path)
+		}
+		paths = append(paths, path)
+		confDirs[filepath.Clean(confDirOf(path))] = true
+	}
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		files[path] = true
+		err = watch(filepath.Dir(path))
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+	for dir := range confDirs {
+		err = watch(dir)
+		if err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+
+	// Reload one change at a time so onChange sees changes in order
+	var mu sync.Mutex
+	reload := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		root.reload(onChange)
+	}
+
+	for _, provider := range root.opts.providers {
+		providerWatcher, ok := provider.(ConfigWatcher)
+		if !ok {
+			continue
+		}
+		err = providerWatcher.Watch(ctx, reload)
+		if err != nil {
+			watcher.Close()
+			return errors.Wrapf(err, "unable to watch config from %s", providerName(provider))
+		}
+	}
+
+	go root.watchFiles(ctx, watcher, files, confDirs, reload)
+	return nil
+}
+
+func (a *App) watchFiles(ctx context.Context, watcher *fsnotify.Watcher, files, confDirs map[string]bool, reload func()) {
+	defer watcher.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			name := filepath.Clean(event.Name)
+			if !(files[name] || confDirs[filepath.Dir(name)]) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			reload()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			// TODO use log
+			fmt.Fprintln(a.Cmd.ErrOrStderr(), "Error watching config:", err)
+		}
+	}
+}
+
+// reload reloads the config and calls onChange with any changes
+func (a *App) reload(onChange func([]ConfigChange)) {
+	changes, err := a.reloadConfig()
+	if err != nil {
+		// Keep our current values until the config is fixed
+		// TODO use log
+		fmt.Fprintln(a.Cmd.ErrOrStderr(), "Error reloading config:", err)
+		return
+	}
+	if len(changes) > 0 && onChange != nil {
+		onChange(changes)
+	}
+}
+
+// reloadConfig reads the config files and providers again and sets only the variables that changed
+// Files and providers are read before the App is locked so readers aren't kept waiting on them
+func (a *App) reloadConfig() ([]ConfigChange, error) {
+	load, err := a.readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	before := a.values()
+	// Values that were encrypted stay redacted even if they no longer are
+	wasEncrypted := a.config.encrypted
+	previous := a.config.configLoad
+	err = a.applyConfig(load)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every changed value is parsed before any are set
+	changes := make([]ConfigChange, 0)
+	sets := make([]func(), 0)
+	a.visit(func(app *App) {
+		changed := make(map[string]bool)
+		for _, postLoad := range app.postLoadFuncs {
+			key := app.key(postLoad.opts)
+			if _, seen := changed[key]; !seen {
+				old, value := before[app][key], app.Viper.Get(key)
+				changed[key] = !reflect.DeepEqual(old, value)
+				if changed[key] {
+					change := ConfigChange{
+						Key: key,
+						Old: app.redactVar(postLoad.opts, old),
+						New: app.redactVar(postLoad.opts, value),
+					}
+					if wasEncrypted[strings.ToLower(key)] {
+						change.Old, change.New = redacted, redacted
+					}
+					changes = append(changes, change)
+				}
+			}
+			if changed[key] && err == nil {
+				var set func()
+				set, err = postLoad.fn()
+				sets = append(sets, set)
+			}
+		}
+	})
+	if err != nil {
+		// Keep our current config and values until the config is fixed
+		restoreErr := a.applyConfig(&previous)
+		if restoreErr != nil {
+			return nil, restoreErr
+		}
+		return nil, err
+	}
+	for _, set := range sets {
+		set()
+	}
+	// Values may now come from another file or provider
+	a.visit(func(app *App) {
+		app.resolveSources()
+	})
+	return changes, nil
+}
+
+// values are the current resolved value of every variable in the App tree
+func (a *App) values() map[*App]map[string]any {
+	values := make(map[*App]map[string]any)
+	a.visit(func(app *App) {
+		values[app] = make(map[string]any)
+		for _, postLoad := range app.postLoadFuncs {
+			key := app.key(postLoad.opts)
+			values[app][key] = app.Viper.Get(key)
+		}
+	})
+	return values
+}
+
+// visit calls fn for the App and every child below it
+func (a *App) visit(fn func(app *App)) {
+	fn(a)
+	for _, child := range a.children {
+		child.visit(fn)
+	}
+}
@@ -0,0 +1,146 @@
+package ezcli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestApp_WatchConfig(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: before\ncount: 1\n")
+
+	app := New(&cobra.Command{Use: "tool"})
+	var name string
+	var count int
+	app.StringVar(&name, "name", "", "usage")
+	app.IntVar(&count, "count", 0, "usage")
+	app.Init(path, "tool")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged variables should not be set again
+	app.mu.Lock()
+	count = 1337
+	app.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := make(chan []ConfigChange, 1)
+	err = app.WatchConfig(ctx, func(changes []ConfigChange) {
+		changed <- changes
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the file in one step so we never read a partial write
+	tmp := writeFile(t, path+".tmp", "name: after\ncount: 1\n")
+	err = os.Rename(tmp, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changes := <-changed:
+		if len(changes) != 1 {
+			t.Fatalf("expected a single change got %+v", changes)
+		}
+		change := changes[0]
+		if change.Key != "name" || change.Old != "before" || change.New != "after" {
+			t.Errorf("unexpected change %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+
+	app.RLock()
+	defer app.RUnlock()
+	if name != "after" {
+		t.Errorf("expected 'after' got '%s'", name)
+	}
+	if count != 1337 {
+		t.Errorf("expected unchanged variable to keep '%d' got '%d'", 1337, count)
+	}
+}
+
+func TestApp_WatchConfigWithoutInit(t *testing.T) {
+	err := subject().WatchConfig(context.Background(), nil)
+	if err == nil {
+		t.Error("expected an error when Init was not called")
+	}
+}
+
+func TestApp_ReloadInvalidValue(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: before\nports: [1, 2]\n")
+
+	app := New(&cobra.Command{Use: "tool"})
+	var name string
+	var ports []int
+	app.StringVar(&name, "name", "", "usage")
+	app.Var(&ports, "ports", []int(nil), "usage")
+	app.Init(path, "tool")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is set when any changed value is invalid
+	writeFile(t, path, "name: after\nports: [1, x]\n")
+	_, err = app.reloadConfig()
+	expected := `invalid value for ports: unable to parse item 2 "x"`
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected '%s' got '%v'", expected, err)
+	}
+	if name != "before" || len(ports) != 2 {
+		t.Errorf("expected 'before' '[1 2]' got '%s' '%v'", name, ports)
+	}
+	if value := app.Viper.GetString("name"); value != "before" {
+		t.Errorf("expected the previous config 'before' got '%s'", value)
+	}
+
+	// Fixing the file applies every change
+	writeFile(t, path, "name: after\nports: [1, 3]\n")
+	changes, err := app.reloadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || name != "after" || ports[1] != 3 {
+		t.Errorf("expected both changes got %+v '%s' '%v'", changes, name, ports)
+	}
+}
+
+func TestApp_ReloadSources(t *testing.T) {
+	path := writeFile(t, filepath.Join(t.TempDir(), "tool.yaml"), "name: before\n")
+
+	app := New(&cobra.Command{Use: "tool"})
+	var name, region string
+	app.StringVar(&name, "name", "", "usage")
+	app.StringVar(&region, "region", "eu", "usage")
+	app.Init(path, "tool")
+	err := app.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.IsSet("region") {
+		t.Error("expected 'region' to be unset")
+	}
+
+	writeFile(t, path, "region: us\n")
+	_, err = app.reloadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source := app.Source("region"); source != (Source{Kind: SourceFile, Detail: path}) {
+		t.Errorf("expected 'region' from '%s' got '%s'", path, source)
+	}
+	if source := app.Source("name"); source.Kind != SourceDefault {
+		t.Errorf("expected 'name' from default got '%s'", source)
+	}
+}